@@ -14,11 +14,26 @@ func loadCommands(app *cli.App) {
 		cli.StringFlag{Name: "milestone", Value: "", Usage: "display issues inside a particular <milestone>."},
 		cli.BoolFlag{Name: "no-trunc", Usage: "do not truncate the issue name"},
 		cli.BoolFlag{Name: "verbose", Usage: "show more verbose output on actions"},
-		cli.IntFlag{Name: "votes", Value: -1, Usage: "display the number of votes '+1' filtered by the <number> specified."},
-		cli.BoolFlag{Name: "vote", Usage: "add '+1' to an specific issue."},
+		cli.BoolFlag{Name: "no-pager", Usage: "don't pipe the diffs, comments and issues into $GORDON_PAGER, $PAGER or less"},
+		cli.StringFlag{Name: "format", Value: gordon.FormatTable, Usage: "output format of the listings: table, json, yaml, csv or a Go template such as '{{.Number}}\\t{{.Title}}'"},
+		cli.StringFlag{Name: "template-file", Value: "", Usage: "read the Go template of --format from a file"},
+		cli.IntFlag{Name: "votes", Value: -1, Usage: "display the number of votes, 👍 reactions or '+1' comments on older issues, filtered by the <number> specified."},
+		cli.BoolFlag{Name: "vote", Usage: "add a 👍 reaction to an specific issue."},
+		cli.StringFlag{Name: "sort", Value: "updated", Usage: "sort the issues by (created or age, updated, comments, votes, priority)"},
+		cli.BoolFlag{Name: "reverse", Usage: "reverse the order of the issues"},
 		cli.BoolFlag{Name: "proposals", Usage: "Only show proposal issues"},
 	}
 
+	filters := []cli.Flag{
+		cli.StringSliceFlag{Name: "label", Usage: "display only issues with this label, can be repeated"},
+		cli.StringSliceFlag{Name: "no-label", Usage: "display only issues without this label, can be repeated"},
+		cli.StringFlag{Name: "created-after", Value: "", Usage: "display only issues opened after a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "created-before", Value: "", Usage: "display only issues opened before a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "updated-before", Value: "", Usage: "display only issues not updated since a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "older-than", Value: "", Usage: "display only issues opened longer ago than a duration, e.g. 14d, 2w or 36h"},
+	}
+	app.Flags = append(app.Flags, filters...)
+
 	app.Commands = []cli.Command{
 		{
 			Name:   "alru",
@@ -45,6 +60,17 @@ func loadCommands(app *cli.App) {
 			Action:      closeCmd,
 			Flags:       []cli.Flag{},
 		},
+		{
+			Name:        "label",
+			Usage:       "Add or remove labels: label ID add|rm NAME..., or label --FILTER... add|rm NAME...",
+			Description: "Without an ID, the labels are changed on every open issue selected by the filtering flags",
+			Action:      labelCmd,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{Name: "dry-run", Usage: "only list the issues that would be changed"},
+				cli.StringFlag{Name: "milestone", Value: "", Usage: "select the issues inside a particular <milestone>"},
+				cli.BoolFlag{Name: "proposals", Usage: "select the proposal issues"},
+			}, filters...),
+		},
 		{
 			Name:   "search",
 			Usage:  "Find issues by state and keyword.",
@@ -3,8 +3,11 @@ package main
 import (
 	"fmt"
 	"github.com/docker/gordon/pkg/gordon"
+	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/urfave/cli"
@@ -124,36 +127,106 @@ func searchCmd(c *cli.Context) error {
 		if err != nil {
 			gordon.Fatalf("%s", err)
 		}
-		fmt.Printf("%c[2K\r", 27)
-		gordon.DisplayIssues(c, issues, c.Bool("no-trunc"))
+		gordon.ClearProgress()
+		render(renderer(c).Issues(issues))
 	} else {
 		fmt.Printf("Please enter a search term\n")
 	}
 	return nil
 }
 
-func addComment(number, comment string) {
+func addComment(c *cli.Context, number, comment string) {
 	cmt, err := m.AddComment(number, comment)
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayCommentAdded(cmt)
+	render(renderer(c).CommentAdded(cmt))
+}
+
+// issueFilter maps the filtering flags to their options
+func issueFilter(c *cli.Context) (filters.IssueFilter, error) {
+	options := filters.IssueFilter{
+		New:       c.Bool("new"),
+		Milestone: c.String("milestone"),
+		Votes:     c.Int("votes"),
+		Proposals: c.Bool("proposals"),
+		Labels:    c.StringSlice("label"),
+		NoLabels:  c.StringSlice("no-label"),
+		Sort:      c.String("sort"),
+		Reverse:   c.Bool("reverse"),
+	}
+
+	err := gordon.ParseTimeFlags(c.String, map[string]*time.Time{
+		"created-after":  &options.CreatedAfter,
+		"created-before": &options.CreatedBefore,
+		"updated-before": &options.UpdatedBefore,
+	}, map[string]*time.Duration{
+		"older-than": &options.OlderThan,
+	})
+	return options, err
+}
+
+// Add or remove labels on an issue, or on every open issue selected by the
+// filtering flags
+func labelCmd(c *cli.Context) error {
+	number, change, err := gordon.ParseLabelArgs(c.Args())
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	numbers := []string{number}
+	if number == "" {
+		if numbers, err = filteredIssueNumbers(c); err != nil {
+			gordon.Fatalf("%s", err)
+		}
+	}
+	render(m.LabelAll(renderer(c), numbers, change, c.Bool("dry-run")))
+	return nil
+}
+
+// filteredIssueNumbers returns the open issues selected by the filtering
+// flags, refusing to select all of them
+func filteredIssueNumbers(c *cli.Context) ([]string, error) {
+	options, err := issueFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	if options.Milestone == "" && !options.Proposals && len(options.Labels) == 0 && len(options.NoLabels) == 0 &&
+		options.CreatedAfter.IsZero() && options.CreatedBefore.IsZero() && options.UpdatedBefore.IsZero() && options.OlderThan == 0 {
+		return nil, fmt.Errorf("Refusing to label every issue, provide an ID or filtering flags")
+	}
+	issues, err := m.GetIssues("open", "", "updated")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting issues: %s", err)
+	}
+	if issues, err = filters.FilterIssues(m, issues, options); err != nil {
+		return nil, fmt.Errorf("Error filtering issues: %s", err)
+	}
+	gordon.ClearProgress()
+	var numbers []string
+	for _, issue := range issues {
+		numbers = append(numbers, strconv.Itoa(issue.Number))
+	}
+	return numbers, nil
 }
 
 func mainCmd(c *cli.Context) error {
 	if !c.Args().Present() {
-		var issues, err = m.GetIssues("open", c.String("assigned"))
+		// sorted client-side once filtered
+		var issues, err = m.GetIssues("open", c.String("assigned"), "updated")
 
 		if err != nil {
 			gordon.Fatalf("Error getting issues: %s", err)
 		}
-		issues, err = filters.FilterIssues(c, issues)
+		options, err := issueFilter(c)
+		if err != nil {
+			gordon.Fatalf("%s", err)
+		}
+		issues, err = filters.FilterIssues(m, issues, options)
 		if err != nil {
 			gordon.Fatalf("Error filtering issues: %s", err)
 		}
-
-		fmt.Printf("%c[2K\r", 27)
-		gordon.DisplayIssues(c, issues, c.Bool("no-trunc"))
+		gordon.ClearProgress()
+		render(renderer(c).Issues(issues))
 		return nil
 	}
 
@@ -163,13 +236,15 @@ func mainCmd(c *cli.Context) error {
 	)
 
 	if comment != "" {
-		addComment(number, comment)
+		addComment(c, number, comment)
 		return nil
 	}
 
 	if c.Bool("vote") {
-		addComment(number, "+1")
-		fmt.Printf("Vote added to the issue: %s", number)
+		if err := m.Vote(number); err != nil {
+			gordon.Fatalf("%s", err)
+		}
+		fmt.Printf("Vote added to the issue: %s\n", number)
 		return nil
 	}
 
@@ -177,7 +252,7 @@ func mainCmd(c *cli.Context) error {
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayIssue(issue, comments)
+	render(gordon.Paged(renderOptions(c), func(r *gordon.Renderer) error { return r.Issue(issue, comments) }))
 	return nil
 }
 
@@ -215,6 +290,28 @@ func authCmd(c *cli.Context) error {
 	return nil
 }
 
+// renderer writes to the standard output with the display flags of the
+// command
+func renderer(c *cli.Context) *gordon.Renderer {
+	return gordon.NewRenderer(os.Stdout, renderOptions(c))
+}
+
+// renderOptions are the display flags of the command
+func renderOptions(c *cli.Context) gordon.RenderOptions {
+	options := gordon.DefaultRenderOptions()
+	options.NoTrunc = c.Bool("no-trunc")
+	// the votes are only counted when filtered or sorted by
+	options.Votes = c.Int("votes") > 0 || c.String("sort") == "votes"
+	return options
+}
+
+// render exits when the output couldn't be written
+func render(err error) {
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+}
+
 func before(c *cli.Context) error {
 	client := gh.NewClient()
 
@@ -231,6 +328,20 @@ func before(c *cli.Context) error {
 
 	// Set verbosity
 	gordon.VerboseOutput = c.Bool("verbose")
+	gordon.NoPager = c.Bool("no-pager")
+
+	if err := gordon.SetFormat(c.String("format")); err != nil {
+		return err
+	}
+	if file := c.String("template-file"); file != "" {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := gordon.SetTemplate(strings.TrimSuffix(string(text), "\n")); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
 
 	return nil
 }
@@ -12,28 +12,54 @@ func loadCommands(app *cli.App) {
 	app.Flags = []cli.Flag{
 		cli.StringFlag{Name: "remote", Value: gordon.GetDefaultGitRemote(), Usage: "git remote to treat as origin"},
 		cli.BoolFlag{Name: "verbose", Usage: "show more verbose output on actions"},
+		cli.BoolFlag{Name: "no-pager", Usage: "don't pipe the diffs, comments and issues into $GORDON_PAGER, $PAGER or less"},
+		cli.StringFlag{Name: "format", Value: gordon.FormatTable, Usage: "output format of the listings: table, json, yaml, csv or a Go template such as '{{.Number}}\\t{{.Title}}'"},
+		cli.StringFlag{Name: "template-file", Value: "", Usage: "read the Go template of --format from a file"},
 	}
 
 	// Filters modify what type of pr to display
 	filters := []cli.Flag{
 		cli.BoolFlag{Name: "no-merge", Usage: "display only prs that cannot be merged"},
+		cli.StringSliceFlag{Name: "mergeable-state", Usage: "display only prs in this mergeable state: clean, dirty, blocked, behind, unstable or unknown, can be repeated"},
 		cli.BoolFlag{Name: "lgtm", Usage: "display the number of LGTM"},
-		cli.StringFlag{Name: "state", Value: "open", Usage: "display prs based on their state"},
+		cli.StringFlag{Name: "state", Value: "open", Usage: "display prs based on their state: open, closed or all"},
 		cli.BoolFlag{Name: "new", Usage: "display prs opened in the last 24 hours"},
 		cli.BoolFlag{Name: "mine", Usage: "display only PRs I care about based on the MAINTAINERS files"},
 		cli.StringFlag{Name: "maintainer", Value: "", Usage: "display only PRs a maintainer cares about based on the MAINTAINERS files"},
-		cli.StringFlag{Name: "sort", Value: "updated", Usage: "sort the prs by (created, updated, popularity, long-running)"},
+		cli.StringFlag{Name: "sort", Value: "updated", Usage: "sort the prs by (created or age, updated, comments, lgtm, size, ci, priority)"},
+		cli.BoolFlag{Name: "reverse", Usage: "reverse the order of the prs"},
 		cli.StringFlag{Name: "assigned", Value: "", Usage: "display only prs assigned to a user"},
 		cli.BoolFlag{Name: "unassigned", Usage: "display only unassigned prs"},
 		cli.StringFlag{Name: "dir", Value: "", Usage: "display only prs that touch this dir"},
 		cli.StringFlag{Name: "extension", Value: "", Usage: "display only prs that have files with this extension (no dot)"},
 		cli.BoolFlag{Name: "cleanup", Usage: "display only cleanup prs"},
+		cli.StringSliceFlag{Name: "label", Usage: "display only prs with this label, can be repeated"},
+		cli.StringSliceFlag{Name: "no-label", Usage: "display only prs without this label, can be repeated"},
+		cli.StringFlag{Name: "created-after", Value: "", Usage: "display only prs opened after a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "created-before", Value: "", Usage: "display only prs opened before a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "updated-before", Value: "", Usage: "display only prs not updated since a date (2006-01-02) or a duration ago (14d)"},
+		cli.StringFlag{Name: "older-than", Value: "", Usage: "display only prs opened longer ago than a duration, e.g. 14d, 2w or 36h"},
+		cli.StringFlag{Name: "idle-since-author", Value: "", Usage: "display only prs waiting on their author for a duration, e.g. 7d"},
+		cli.StringFlag{Name: "idle-since-maintainer", Value: "", Usage: "display only prs waiting on the maintainers for a duration, e.g. 7d"},
+		cli.StringFlag{Name: "base", Value: "", Usage: "display only prs targeting a branch, or the branches matching a pattern such as 'release-*'"},
+		cli.BoolFlag{Name: "draft", Usage: "display only draft prs"},
+		cli.BoolFlag{Name: "no-draft", Usage: "hide draft prs"},
+		cli.BoolFlag{Name: "from-fork", Usage: "display only prs from a fork"},
+		cli.BoolFlag{Name: "same-repo", Usage: "display only prs from a branch of the repository itself"},
+		cli.BoolFlag{Name: "maintainer-can-modify", Usage: "display only prs the maintainers can push to"},
+		cli.IntFlag{Name: "min-lines", Usage: "display only prs adding and deleting at least this many lines"},
+		cli.IntFlag{Name: "max-lines", Usage: "display only prs adding and deleting at most this many lines"},
+		cli.IntFlag{Name: "max-files", Usage: "display only prs changing at most this many files"},
+		cli.StringFlag{Name: "ci", Value: "", Usage: "display only prs whose status is success, failure or pending"},
+		cli.StringFlag{Name: "ci-context", Value: "", Usage: "only consider the status reported by this context, e.g. jenkins"},
+		cli.StringFlag{Name: "filter", Value: "", Usage: "display only prs matching an expression, e.g. 'label:bug AND NOT draft AND age>14d' (see pulls filters)"},
 	}
 	app.Flags = append(app.Flags, filters...)
 
 	// Options modify how to display prs
 	options := []cli.Flag{
 		cli.BoolFlag{Name: "no-trunc", Usage: "don't truncate pr name"},
+		cli.BoolFlag{Name: "details", Usage: "fetch each pr to add the size and ci columns, two more requests per pr"},
 		cli.StringFlag{Name: "user", Value: "", Usage: "display only prs from <user>"},
 		cli.StringFlag{Name: "comment", Value: "", Usage: "add a comment to the pr"},
 	}
@@ -46,6 +72,11 @@ func loadCommands(app *cli.App) {
 			Usage:  "List information about the current repository",
 			Action: repositoryInfoCmd,
 		},
+		{
+			Name:   "filters",
+			Usage:  "List the attributes --filter expressions can test",
+			Action: filtersCmd,
+		},
 		{
 			Name:   "comment",
 			Usage:  "Leave a comment on a pull request",
@@ -79,15 +110,58 @@ func loadCommands(app *cli.App) {
 			Action: mergeCmd,
 			Flags: []cli.Flag{
 				cli.StringFlag{Name: "m", Value: "", Usage: "commit message for merge"},
-				cli.BoolFlag{Name: "force", Usage: "merge a pull request that has not been approved"},
+				cli.BoolFlag{Name: "force", Usage: "merge a pull request that does not satisfy the merge policy"},
 			},
 		},
+		{
+			Name:   "policy",
+			Usage:  "Check a pull request against the merge policy of the repository",
+			Action: policyCmd,
+		},
 		{
 			Name:   "close",
 			Usage:  "Close a pull request without merging it",
 			Action: closeCmd,
 			Flags:  []cli.Flag{},
 		},
+		{
+			Name:        "label",
+			Usage:       "Add or remove labels: label ID add|rm NAME..., or label --FILTER... add|rm NAME...",
+			Description: "Without an ID, the labels are changed on every pr selected by the filtering flags",
+			Action:      labelCmd,
+			Flags:       append([]cli.Flag{cli.BoolFlag{Name: "dry-run", Usage: "only list the prs that would be changed"}}, filters...),
+		},
+		{
+			Name:        "grep",
+			Usage:       "Search the titles, bodies, comments and diffs of the prs: grep PATTERN",
+			Description: "PATTERN is a regular expression, the prs searched can be narrowed by the filtering flags",
+			Action:      grepCmd,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{Name: "diff", Usage: "search the diffs too"},
+				cli.BoolFlag{Name: "ignore-case", Usage: "ignore the case of the pattern"},
+				cli.IntFlag{Name: "context", Usage: "show this many lines around each match"},
+			}, filters...),
+		},
+		{
+			Name:        "overlaps",
+			Usage:       "List the open prs touching the same files as a pr: overlaps ID, or overlaps --matrix",
+			Description: "The prs compared can be narrowed by the filtering flags",
+			Action:      overlapsCmd,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{Name: "matrix", Usage: "print the overlap of every pair of prs"},
+				cli.BoolFlag{Name: "no-trunc", Usage: "don't truncate the titles and files"},
+			}, filters...),
+		},
+		{
+			Name:        "tui",
+			Usage:       "Browse the prs and act on them in a full-screen interface",
+			Description: "The prs listed can be narrowed by the filtering flags, the keys are listed at the bottom of the screen",
+			Action:      tuiCmd,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{Name: "no-trunc", Usage: "don't truncate pr name"},
+				cli.BoolFlag{Name: "details", Usage: "fetch each pr to add the size and ci columns, two more requests per pr"},
+			}, filters...),
+		},
 		{
 			Name:   "checkout",
 			Usage:  "Checkout a pull request into your local repo",
@@ -97,6 +171,10 @@ func loadCommands(app *cli.App) {
 			Name:   "send",
 			Usage:  "Send a new pull request, or overwrite an existing one",
 			Action: sendCmd,
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "reviewers", Usage: "list the reviewers of the changes in the body of the new pull request"},
+				cli.StringFlag{Name: "range", Usage: "revision range used to find the reviewers (default: the current branch against the upstream base)"},
+			},
 		},
 		{
 			Name:   "approve",
@@ -124,8 +202,41 @@ func loadCommands(app *cli.App) {
 		},
 		{
 			Name:   "reviewers",
-			Usage:  "Use the hierarchy of MAINTAINERS files to list who should review a pull request",
+			Usage:  "Use the hierarchy of MAINTAINERS files to list who should review a pull request or local changes",
 			Action: reviewersCmd,
+			Flags: []cli.Flag{
+				cli.BoolFlag{Name: "request", Usage: "request a review from the maintainers who were not asked yet"},
+				cli.BoolFlag{Name: "ping", Usage: "leave a comment mentioning the maintainers who were not pinged yet"},
+				cli.StringFlag{Name: "template", Usage: "provide a template for the ping comment"},
+				cli.StringFlag{Name: "range", Usage: "list the reviewers of a local revision range instead of a pull request (default: the current branch against the upstream base)"},
+			},
+		},
+		{
+			Name:  "maintainers",
+			Usage: "Explore who maintains what using the hierarchy of MAINTAINERS files",
+			Subcommands: []cli.Command{
+				{
+					Name:   "tree",
+					Usage:  "Show the directory tree with the maintainers of each path",
+					Action: maintainersTreeCmd,
+					Flags: []cli.Flag{
+						cli.IntFlag{Name: "depth", Value: 0, Usage: "only descend N directories deep (0 for no limit)"},
+					},
+				},
+				{
+					Name:   "show",
+					Usage:  "Show the paths owned by a maintainer and the open pull requests touching them",
+					Action: maintainersShowCmd,
+				},
+			},
+		},
+		{
+			Name:   "workload",
+			Usage:  "Show the open pull requests touching the paths of each maintainer, assigned to them or waiting on their answer",
+			Action: workloadCmd,
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "maintainer", Value: "", Usage: "only show the workload of this maintainer"},
+			},
 		},
 		{
 			Name:   "contributors",
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"github.com/docker/gordon/pkg/gordon"
 	"io"
@@ -11,49 +12,101 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/aybabtme/color/brush"
 	"github.com/urfave/cli"
 	gh "github.com/crosbymichael/octokat"
 	"github.com/docker/gordon/pkg/filters"
+	"github.com/docker/gordon/pkg/tui"
 )
 
 var (
 	m            *gordon.MaintainerManager
+	sizes        = gordon.DefaultSizes
 	templatePath = filepath.Join(os.Getenv("HOME"), ".gordon/templates")
 )
 
 func displayAllPullRequests(c *cli.Context) error {
-	prs, err := m.GetPullRequests(c.String("state"), c.String("sort"))
+	options, err := pullRequestFilter(c)
 	if err != nil {
-		gordon.Fatalf("Error getting pull requests %s", err)
-	}
-
-	var needFullPr, needComments bool
-
-	if c.Bool("no-merge") {
-		needFullPr = true
-	}
-	if c.Bool("lgtm") {
-		needComments = true
+		gordon.Fatalf("%s", err)
 	}
-
-	if needFullPr || needComments {
-		prs = m.GetFullPullRequests(prs, needFullPr, needComments)
+	prs, err := m.GetPullRequestsForBase(c.String("state"), options.APISort(), options.APIBase())
+	if err != nil {
+		gordon.Fatalf("Error getting pull requests %s", err)
 	}
-
-	prs, err = filters.FilterPullRequests(c, prs)
+	prs, err = filters.FilterPullRequests(m, prs, options)
 	if err != nil {
 		gordon.Fatalf("Error filtering pull requests %s", err)
 	}
+	if c.Bool("details") {
+		// for the size and ci columns, what was fetched to filter isn't
+		// fetched again
+		prs = m.FetchPullRequests(prs, gordon.Need{Full: true, Status: true})
+	}
 
-	fmt.Printf("%c[2K\r", 27)
-	gordon.DisplayPullRequests(c, prs, c.Bool("no-trunc"))
+	gordon.ClearProgress()
+	render(renderer(c).PullRequests(prs))
 	return nil
 }
 
+// pullRequestFilter maps the filtering flags to their options
+func pullRequestFilter(c *cli.Context) (filters.PullRequestFilter, error) {
+	options := filters.PullRequestFilter{
+		New:        c.Bool("new"),
+		User:       c.String("user"),
+		Cleanup:    c.Bool("cleanup"),
+		Maintainer: c.String("maintainer"),
+		Dir:        c.String("dir"),
+		Extension:  c.String("extension"),
+		Unassigned: c.Bool("unassigned"),
+		Assigned:   c.String("assigned"),
+		NoMerge:    c.Bool("no-merge"),
+		Labels:     c.StringSlice("label"),
+		NoLabels:   c.StringSlice("no-label"),
+		CI:         c.String("ci"),
+		CIContext:  c.String("ci-context"),
+		Base:       c.String("base"),
+		Draft:      c.Bool("draft"),
+		NoDraft:    c.Bool("no-draft"),
+		FromFork:   c.Bool("from-fork"),
+		SameRepo:   c.Bool("same-repo"),
+		MinLines:   c.Int("min-lines"),
+		MaxLines:   c.Int("max-lines"),
+		MaxFiles:   c.Int("max-files"),
+		Expr:       c.String("filter"),
+		LGTM:       c.Bool("lgtm"),
+		Sort:       c.String("sort"),
+		Reverse:    c.Bool("reverse"),
+
+		MergeableStates:     c.StringSlice("mergeable-state"),
+		MaintainerCanModify: c.Bool("maintainer-can-modify"),
+	}
+	if options.Maintainer == "" && c.Bool("mine") {
+		email, err := gordon.GetMaintainerManagerEmail()
+		if err != nil {
+			return options, err
+		}
+		options.Maintainer = email
+	}
+
+	err := gordon.ParseTimeFlags(c.String, map[string]*time.Time{
+		"created-after":  &options.CreatedAfter,
+		"created-before": &options.CreatedBefore,
+		"updated-before": &options.UpdatedBefore,
+	}, map[string]*time.Duration{
+		"older-than":            &options.OlderThan,
+		"idle-since-author":     &options.IdleSinceAuthor,
+		"idle-since-maintainer": &options.IdleSinceMaintainer,
+	})
+	return options, err
+}
+
 func displayAllPullRequestFiles(c *cli.Context, number string) error {
 	prfs, err := m.GetPullRequestFiles(number)
 	if err == nil {
@@ -75,12 +128,12 @@ func alruCmd(c *cli.Context) error {
 	return nil
 }
 
-func addComment(number, comment string) {
+func addComment(c *cli.Context, number, comment string) {
 	cmt, err := m.AddComment(number, comment)
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayCommentAdded(cmt)
+	render(renderer(c).CommentAdded(cmt))
 }
 
 func repositoryInfoCmd(c *cli.Context) error {
@@ -109,6 +162,26 @@ func mergeCmd(c *cli.Context) error {
 	return nil
 }
 
+// Check a pull request against the merge policy of the repository
+func policyCmd(c *cli.Context) error {
+	if !c.Args().Present() {
+		gordon.Fatalf("usage: policy ID")
+	}
+	pr, err := m.GetPullRequest(c.Args()[0])
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	result, err := m.EvaluatePolicy(pr)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	render(renderer(c).Policy(pr, result))
+	if !result.Passed() {
+		os.Exit(1)
+	}
+	return nil
+}
+
 func checkoutCmd(c *cli.Context) error {
 	if !c.Args().Present() {
 		gordon.Fatalf("usage: checkout ID")
@@ -153,9 +226,7 @@ func showCmd(c *cli.Context) error {
 	}
 	defer patch.Body.Close()
 
-	if err := gordon.DisplayPatch(patch.Body); err != nil {
-		gordon.Fatalf("%s", err)
-	}
+	render(gordon.Paged(renderOptions(c), func(r *gordon.Renderer) error { return r.Patch(patch.Body) }))
 	return nil
 }
 
@@ -165,49 +236,406 @@ func contributorsCmd(c *cli.Context) error {
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayContributors(c, contributors)
+	render(renderer(c).Contributors(contributors))
 	return nil
 }
 
-// Show the reviewers for this pull request
-func reviewersCmd(c *cli.Context) error {
+// Show the directory tree of the repository with the maintainers of each path
+func maintainersTreeCmd(c *cli.Context) error {
+	toplevel, err := gordon.GetTopLevelGitRepo()
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	maintainers, err := gordon.GetMaintainersFromRepo(toplevel, true)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	tree, err := gordon.GetOwnershipTree(toplevel, maintainers, c.Int("depth"))
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	render(renderer(c).OwnershipTree(tree))
+	return nil
+}
+
+// Show the paths owned by a maintainer and the open pull requests touching them
+func maintainersShowCmd(c *cli.Context) error {
+	if !c.Args().Present() {
+		gordon.Fatalf("usage: maintainers show USER")
+	}
+	maintainer := strings.TrimPrefix(c.Args().First(), "@")
+	toplevel, err := gordon.GetTopLevelGitRepo()
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	maintainers, err := gordon.GetMaintainersFromRepo(toplevel, true)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	paths, exists := maintainers[maintainer]
+	if !exists {
+		gordon.Fatalf("%s is not listed in any %s file", maintainer, gordon.MaintainerFileName)
+	}
+	sort.Strings(paths)
+
+	prs, err := m.GetPullRequests("open", "updated")
+	if err != nil {
+		gordon.Fatalf("Error getting pull requests %s", err)
+	}
+	m.LoadReviewers(prs, maintainers)
+	var touching []*gordon.PullRequest
+	for _, p := range prs {
+		if p.ReviewedBy(maintainer) {
+			touching = append(touching, p)
+		}
+	}
+
+	gordon.ClearProgress()
+	render(renderer(c).Maintainer(maintainer, paths, touching))
+	return nil
+}
+
+// Browse the pull requests selected by the filtering flags in a full-screen
+// interface
+func tuiCmd(c *cli.Context) error {
+	options, err := pullRequestFilter(c)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	// the progress dots would be drawn over the interface
+	gordon.Quiet = true
+	if err := runTUI(c.String("state"), options, c.Bool("details"), renderOptions(c)); err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	return nil
+}
+
+// runTUI restores the terminal before returning, Fatalf exits without
+// running the deferred calls
+func runTUI(state string, options filters.PullRequestFilter, details bool, render gordon.RenderOptions) error {
+	screen, err := tui.NewTerminalScreen(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer screen.Close()
+	return tui.New(screen, tui.NewManagerBackend(m, state, options, details), render).Run()
+}
+
+// Search the titles, bodies, comments and optionally the diffs of the pull
+// requests selected by the filtering flags
+func grepCmd(c *cli.Context) error {
 	if !c.Args().Present() {
-		gordon.Fatalf("usage: reviewers ID")
+		gordon.Fatalf("usage: grep PATTERN")
+	}
+	pattern := c.Args().First()
+	if c.Bool("ignore-case") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		gordon.Fatalf("Invalid pattern: %s", err)
+	}
+	options, err := pullRequestFilter(c)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	prs, err := m.GetPullRequestsForBase(c.String("state"), options.APISort(), options.APIBase())
+	if err != nil {
+		gordon.Fatalf("Error getting pull requests %s", err)
+	}
+	if prs, err = filters.FilterPullRequests(m, prs, options); err != nil {
+		gordon.Fatalf("Error filtering pull requests %s", err)
+	}
+	prs = m.FetchPullRequests(prs, gordon.Need{Comments: true, Diff: c.Bool("diff")})
+	gordon.ClearProgress()
+
+	var matches []gordon.GrepMatch
+	for _, p := range prs {
+		matches = append(matches, p.Grep(re, c.Int("context"))...)
+	}
+	render(renderer(c).GrepMatches(matches, c.Int("context")))
+	return nil
+}
+
+// List the open pull requests changing the same files or lines as a pull
+// request, or the overlap of every pair of them
+func overlapsCmd(c *cli.Context) error {
+	if !c.Args().Present() && !c.Bool("matrix") {
+		gordon.Fatalf("usage: overlaps ID or overlaps --matrix")
+	}
+	options, err := pullRequestFilter(c)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	prs, err := m.GetPullRequestsForBase("open", options.APISort(), options.APIBase())
+	if err != nil {
+		gordon.Fatalf("Error getting pull requests %s", err)
+	}
+	if prs, err = filters.FilterPullRequests(m, prs, options); err != nil {
+		gordon.Fatalf("Error filtering pull requests %s", err)
 	}
+	prs = m.FetchPullRequests(prs, gordon.Need{Diff: true})
 
+	if c.Bool("matrix") {
+		prs, matrix := gordon.OverlapMatrix(prs)
+		gordon.ClearProgress()
+		render(renderer(c).OverlapMatrix(prs, matrix))
+		return nil
+	}
+
+	number := c.Args().First()
+	pr, err := m.GetFullPullRequest(number)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	if prs := m.FetchPullRequests([]*gordon.PullRequest{pr}, gordon.Need{Diff: true}); len(prs) == 0 {
+		gordon.Fatalf("Error getting the diff of #%s", number)
+	}
+	overlaps := gordon.FindOverlaps(pr, prs)
+	gordon.ClearProgress()
+	render(renderer(c).Overlaps(pr, overlaps))
+	return nil
+}
+
+// Add or remove labels on a pull request, or on every pull request selected
+// by the filtering flags
+func labelCmd(c *cli.Context) error {
+	number, change, err := gordon.ParseLabelArgs(c.Args())
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	numbers := []string{number}
+	if number == "" {
+		if numbers, err = filteredPullRequestNumbers(c); err != nil {
+			gordon.Fatalf("%s", err)
+		}
+	}
+	render(m.LabelAll(renderer(c), numbers, change, c.Bool("dry-run")))
+	return nil
+}
+
+// filteredPullRequestNumbers returns the pull requests selected by the
+// filtering flags, refusing to select all of them
+func filteredPullRequestNumbers(c *cli.Context) ([]string, error) {
+	options, err := pullRequestFilter(c)
+	if err != nil {
+		return nil, err
+	}
+	if filter, err := options.Compile(); err != nil {
+		return nil, err
+	} else if filter == nil {
+		return nil, fmt.Errorf("Refusing to label every pull request, provide an ID or filtering flags")
+	}
+	prs, err := m.GetPullRequests(c.String("state"), options.APISort())
+	if err != nil {
+		return nil, fmt.Errorf("Error getting pull requests %s", err)
+	}
+	if prs, err = filters.FilterPullRequests(m, prs, options); err != nil {
+		return nil, fmt.Errorf("Error filtering pull requests %s", err)
+	}
+	gordon.ClearProgress()
+	var numbers []string
+	for _, p := range prs {
+		numbers = append(numbers, strconv.Itoa(p.Number))
+	}
+	return numbers, nil
+}
+
+// List the attributes of the filter expressions
+func filtersCmd(c *cli.Context) error {
+	fmt.Println("Operators: = != < <= > >= ~ (regexp) : (contains, or a parent directory for paths)")
+	fmt.Println("Combine with AND, OR, NOT and parentheses, e.g. 'label:bug AND NOT draft AND age>14d'")
+	fmt.Println()
+	for _, help := range filters.Attributes() {
+		fmt.Println(help)
+	}
+	return nil
+}
+
+// Show what's waiting on each maintainer in the open pull requests
+func workloadCmd(c *cli.Context) error {
+	toplevel, err := gordon.GetTopLevelGitRepo()
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	maintainers, err := gordon.GetMaintainersFromRepo(toplevel, true)
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	prs, err := m.GetPullRequests("open", "updated")
+	if err != nil {
+		gordon.Fatalf("Error getting pull requests %s", err)
+	}
+	prs = m.GetFullPullRequests(prs, false, true)
+	m.LoadReviewers(prs, maintainers)
+
+	workloads := gordon.GetWorkloads(prs, maintainers)
+	if maintainer := c.String("maintainer"); maintainer != "" {
+		var selected []*gordon.Workload
+		for _, w := range workloads {
+			if w.Maintainer == maintainer {
+				selected = append(selected, w)
+			}
+		}
+		workloads = selected
+	}
+
+	gordon.ClearProgress()
+	render(renderer(c).Workloads(workloads))
+	return nil
+}
+
+// Show the reviewers for a pull request, a patch on stdin or the local changes
+// With --request or --ping the reviewers are also asked to review the pull request
+func reviewersCmd(c *cli.Context) error {
 	var (
-		patch      io.Reader
 		patchBytes []byte
-		number     = c.Args()[0]
+		pr         *gh.PullRequest
+		number     = c.Args().First()
+		err        error
 	)
 
-	if number == "-" {
-		patch = os.Stdin
-	} else {
-		pr, err := m.GetPullRequest(number)
+	switch {
+	case number == "" || c.IsSet("range"):
+		// review the local changes that are not upstream yet
+		patchBytes, err = gordon.GetDiffForRange(localRange(c))
+	case number == "-":
+		patchBytes, err = ioutil.ReadAll(os.Stdin)
+	default:
+		pr, err = m.GetPullRequest(number)
 		if err != nil {
 			gordon.Fatalf("%s", err)
 		}
+		patchBytes, err = gordon.GetDiff(pr)
+	}
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
 
-		resp, err := http.Get(pr.DiffURL)
-		if err != nil {
-			gordon.Fatalf("%s", err)
-		}
-		patch = resp.Body
-		defer resp.Body.Close()
+	reviewers, err := gordon.GetReviewersForPR(patchBytes, c.Bool("request") || c.Bool("ping"))
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	if !c.Bool("request") && !c.Bool("ping") {
+		render(renderer(c).Reviewers(reviewers))
+		return nil
+	}
+
+	if pr == nil {
+		gordon.Fatalf("usage: reviewers --request|--ping ID")
 	}
+	logins := gordon.ReviewerLogins(reviewers, pr.User.Login)
+	if len(logins) == 0 {
+		gordon.Fatalf("No reviewers with a github username found for %s", number)
+	}
+	if c.Bool("request") {
+		requestReviewers(number, logins)
+	}
+	if c.Bool("ping") {
+		pingReviewers(c, pr, logins, c.String("template"))
+	}
+	return nil
+}
 
-	patchBytes, err := ioutil.ReadAll(patch)
+// Return the revision range given with --range, or the current branch
+// against the upstream base
+func localRange(c *cli.Context) string {
+	if r := c.String("range"); r != "" {
+		return r
+	}
+	return gordon.GetUpstreamBase(c.GlobalString("remote")) + "...HEAD"
+}
+
+// Request a review from every login that was not asked before
+func requestReviewers(number string, logins []string) {
+	requested, err := m.GetRequestedReviewers(number)
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
+	logins = excludeLogins(logins, requested)
+	if len(logins) == 0 {
+		fmt.Printf("Reviews were already requested for PR %s\n", brush.Green(number))
+		return
+	}
+	if err := m.RequestReviewers(number, logins); err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	fmt.Printf("Requested reviews for PR %s from %s\n", brush.Green(number), strings.Join(logins, ", "))
+}
+
+const defaultPingTemplate = `{{.Mentions}} this pull request touches files you maintain, could you please take a look?`
 
-	reviewers, err := gordon.GetReviewersForPR(patchBytes, false)
+type pingData struct {
+	Number    int
+	Title     string
+	Author    string
+	Reviewers []string
+	Mentions  string
+}
+
+// Leave a comment mentioning every login that was not pinged before
+func pingReviewers(c *cli.Context, pr *gh.PullRequest, logins []string, templateName string) {
+	number := strconv.Itoa(pr.Number)
+	comments, err := m.GetComments(number)
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayReviewers(c, reviewers)
-	return nil
+	logins = excludeLogins(logins, gordon.GetPingedReviewers(comments))
+	if len(logins) == 0 {
+		fmt.Printf("Reviewers were already pinged on PR %s\n", brush.Green(number))
+		return
+	}
+
+	text := defaultPingTemplate
+	if templateName != "" {
+		f, err := openTemplate(templateName)
+		if err != nil {
+			gordon.Fatalf("%v", err)
+		}
+		defer f.Close()
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			gordon.Fatalf("%v", err)
+		}
+		text = string(b)
+	}
+	tmpl, err := template.New("ping").Parse(text)
+	if err != nil {
+		gordon.Fatalf("%v", err)
+	}
+
+	mentions := make([]string, len(logins))
+	for i, l := range logins {
+		mentions[i] = "@" + l
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, pingData{
+		Number:    pr.Number,
+		Title:     pr.Title,
+		Author:    pr.User.Login,
+		Reviewers: logins,
+		Mentions:  strings.Join(mentions, " "),
+	}); err != nil {
+		gordon.Fatalf("%v", err)
+	}
+	fmt.Fprintf(&body, "\n\n%s\n", gordon.PingMarker)
+
+	addComment(c, number, body.String())
+}
+
+// Return the logins that are not part of `exclude`
+func excludeLogins(logins, exclude []string) []string {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[e] = true
+	}
+	out := []string{}
+	for _, l := range logins {
+		if !skip[l] {
+			out = append(out, l)
+		}
+	}
+	return out
 }
 
 // This is the top level command for
@@ -223,15 +651,20 @@ func mainCmd(c *cli.Context) error {
 	)
 
 	if comment != "" {
-		addComment(number, comment)
+		addComment(c, number, comment)
 		return nil
 	}
-	pr, err := m.GetPullRequest(number)
+	pr, err := m.GetFullPullRequest(number)
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	status, err := m.GetStatus(pr)
-	gordon.DisplayPullRequest(pr, status)
+	if err := m.ResolveMergeability([]*gordon.PullRequest{pr}, gordon.MergeabilityTimeout); err != nil {
+		gordon.Fatalf("%s", err)
+	}
+	status, err := m.GetStatus(pr.PullRequest)
+	// the approvals are only an indication, don't fail when MAINTAINERS can't be read
+	approval, _ := m.GetApproval(pr.PullRequest)
+	render(renderer(c).PullRequest(pr, status, approval))
 	return nil
 }
 
@@ -243,7 +676,7 @@ func commentsCmd(c *cli.Context) error {
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	gordon.DisplayComments(comments)
+	render(gordon.Paged(renderOptions(c), func(r *gordon.Renderer) error { return r.Comments(comments) }))
 	return nil
 }
 
@@ -289,31 +722,14 @@ func takeCmd(c *cli.Context) error {
 		gordon.Fatalf("usage: take ID")
 	}
 	number := c.Args()[0]
-	pr, err := m.GetPullRequest(number)
-	if err != nil {
-		gordon.Fatalf("%s", err)
-	}
-	user, err := m.GetGithubUser()
+	user, assigned, err := m.Take(number, c.Bool("steal"))
 	if err != nil {
 		gordon.Fatalf("%s", err)
 	}
-	if user == nil {
-		gordon.Fatalf("%v", gordon.ErrNoUsernameKnown)
-	}
-	if pr.Assignee != nil && !c.Bool("steal") {
-		gordon.Fatalf("Use --steal to steal the PR from %s", pr.Assignee.Login)
-	}
-	pr.Assignee = user
-	patchedPR, err := m.PatchPullRequest(number, pr)
-	if err != nil {
-		gordon.Fatalf("%s", err)
-	}
-	if patchedPR.Assignee.Login != user.Login {
-		m.AddComment(number, "#volunteer")
+	if !assigned {
 		fmt.Printf("No permission to assign. You '%s' was added as #volunteer.\n", user.Login)
 	} else {
-		m.AddComment(number, fmt.Sprintf("#assignee=%s", patchedPR.Assignee.Login))
-		fmt.Printf("Assigned PR %s to %s\n", brush.Green(number), patchedPR.Assignee.Login)
+		fmt.Printf("Assigned PR %s to %s\n", brush.Green(number), user.Login)
 	}
 	return nil
 }
@@ -323,22 +739,7 @@ func dropCmd(c *cli.Context) error {
 		gordon.Fatalf("usage: drop ID")
 	}
 	number := c.Args()[0]
-	pr, err := m.GetPullRequest(number)
-	if err != nil {
-		gordon.Fatalf("%s", err)
-	}
-	user, err := m.GetGithubUser()
-	if err != nil {
-		gordon.Fatalf("%s", err)
-	}
-	if user == nil {
-		gordon.Fatalf("%v", gordon.ErrNoUsernameKnown)
-	}
-	if pr.Assignee == nil || pr.Assignee.Login != user.Login {
-		gordon.Fatalf("Can't drop %s: it's not yours.", number)
-	}
-	pr.Assignee = nil
-	if _, err := m.PatchPullRequest(number, pr); err != nil {
+	if err := m.Drop(number); err != nil {
 		gordon.Fatalf("%s", err)
 	}
 	fmt.Printf("Unassigned PR %s\n", brush.Green(number))
@@ -361,17 +762,10 @@ func commentCmd(c *cli.Context) error {
 	defer os.Remove(tmp.Name())
 
 	if template := c.String("template"); template != "" {
-		f, err := os.Open(template)
-
+		f, err := openTemplate(template)
 		if err != nil {
-			path := filepath.Join(templatePath, filepath.Base(c.String("template")))
-
-			f, err = os.Open(path)
-			if err != nil {
-				gordon.Fatalf("%v", err)
-			}
+			gordon.Fatalf("%v", err)
 		}
-
 		defer f.Close()
 
 		if _, err := io.Copy(tmp, f); err != nil {
@@ -402,6 +796,15 @@ func commentCmd(c *cli.Context) error {
 	return nil
 }
 
+// Open a comment template, either from its path or by name from templatePath
+func openTemplate(name string) (*os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return os.Open(filepath.Join(templatePath, filepath.Base(name)))
+	}
+	return f, nil
+}
+
 func closeCmd(c *cli.Context) error {
 	if !c.Args().Present() {
 		gordon.Fatalf("Please enter the issue's number")
@@ -440,10 +843,16 @@ func sendCmd(c *cli.Context) error {
 		if err := gordon.Git("push", "-f", fmt.Sprintf("ssh://git@github.com/%s/%s", user.Login, repo.Name), "HEAD:refs/heads/"+brName); err != nil {
 			gordon.Fatalf("git push: %v", err)
 		}
-		prBase := "master"
+		// the pull request is based on the branch the local range is compared to
+		remote := c.GlobalString("remote")
+		prBase := strings.TrimPrefix(gordon.GetUpstreamBase(remote), remote+"/")
 		prHead := fmt.Sprintf("%s:%s", user.Login, brName)
+		var body string
+		if c.Bool("reviewers") {
+			body = reviewersBody(c, user.Login)
+		}
 		fmt.Printf("Creating pull request from %s to %s\n", prBase, prHead)
-		pr, err := m.CreatePullRequest(prBase, prHead, string(commitMsg), "")
+		pr, err := m.CreatePullRequest(prBase, prHead, string(commitMsg), body)
 		if err != nil {
 			gordon.Fatalf("create pull request: %v", err)
 		}
@@ -463,6 +872,26 @@ func sendCmd(c *cli.Context) error {
 	return nil
 }
 
+// Return the pull request body listing the reviewers of the local changes
+func reviewersBody(c *cli.Context, author string) string {
+	patch, err := gordon.GetDiffForRange(localRange(c))
+	if err != nil {
+		gordon.Fatalf("%v", err)
+	}
+	reviewers, err := gordon.GetReviewersForPR(patch, true)
+	if err != nil {
+		gordon.Fatalf("%v", err)
+	}
+	logins := gordon.ReviewerLogins(reviewers, author)
+	if len(logins) == 0 {
+		return ""
+	}
+	for i, l := range logins {
+		logins[i] = "@" + l
+	}
+	return fmt.Sprintf("Reviewers: %s\n", strings.Join(logins, " "))
+}
+
 // I need to parse the output of git!
 func git(args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
@@ -537,6 +966,35 @@ func compareCmd(c *cli.Context) error {
 	return nil
 }
 
+// renderer writes to the standard output with the display flags of the
+// command
+func renderer(c *cli.Context) *gordon.Renderer {
+	return gordon.NewRenderer(os.Stdout, renderOptions(c))
+}
+
+// renderOptions are the display flags of the command
+func renderOptions(c *cli.Context) gordon.RenderOptions {
+	options := gordon.DefaultRenderOptions()
+	options.Sizes = sizes
+	options.NoTrunc = c.Bool("no-trunc")
+	options.LGTM = c.Bool("lgtm")
+	options.CIContext = c.String("ci-context")
+	options.Top = c.Int("top")
+	if c.Bool("additions") {
+		options.ContributorsBy = "additions"
+	} else if c.Bool("deletions") {
+		options.ContributorsBy = "deletions"
+	}
+	return options
+}
+
+// render exits when the output couldn't be written
+func render(err error) {
+	if err != nil {
+		gordon.Fatalf("%s", err)
+	}
+}
+
 func before(c *cli.Context) error {
 	client := gh.NewClient()
 
@@ -553,6 +1011,25 @@ func before(c *cli.Context) error {
 
 	// Set verbosity
 	gordon.VerboseOutput = c.Bool("verbose")
+	gordon.NoPager = c.Bool("no-pager")
+
+	// the size column is only an indication, fall back to the default sizes
+	if sizes, err = gordon.LoadSizes(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s, using the default sizes\n", err)
+	}
+
+	if err := gordon.SetFormat(c.String("format")); err != nil {
+		return err
+	}
+	if file := c.String("template-file"); file != "" {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := gordon.SetTemplate(strings.TrimSuffix(string(text), "\n")); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
 
 	return nil
 }
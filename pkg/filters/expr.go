@@ -0,0 +1,381 @@
+package filters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+// Filter selects pull requests. Filters are built from the options of a
+// PullRequestFilter or parsed from an expression, and combined with And, Or
+// and Not.
+type Filter interface {
+	// Match returns true if the pull request passes the filter
+	Match(p *gordon.PullRequest, env *Env) bool
+	// Need returns the data that must be fetched for the pull requests
+	// before they can be matched
+	Need() gordon.Need
+	String() string
+}
+
+// Env holds what's shared by the evaluations of a filter
+type Env struct {
+	Now time.Time
+	// Maintainers are the maintainers of the files of the repository, as
+	// returned by gordon.GetMaintainersFromRepo. They must be set for the
+	// filters that need them.
+	Maintainers map[string][]string
+
+	logins map[string]bool
+}
+
+// NewEnv returns an environment evaluating filters as of now with the
+// maintainers of the repository
+func NewEnv(maintainers map[string][]string) *Env {
+	logins := make(map[string]bool, len(maintainers))
+	for maintainer := range maintainers {
+		logins[strings.ToLower(maintainer)] = true
+	}
+	return &Env{Now: time.Now(), Maintainers: maintainers, logins: logins}
+}
+
+// IsMaintainer returns true if `login` is the github username of one of the
+// maintainers of the repository
+func (e *Env) IsMaintainer(login string) bool {
+	return e.logins[strings.ToLower(login)]
+}
+
+type andFilter struct{ left, right Filter }
+
+func (e *andFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	return e.left.Match(p, env) && e.right.Match(p, env)
+}
+func (e *andFilter) Need() gordon.Need { return e.left.Need().Merge(e.right.Need()) }
+func (e *andFilter) String() string    { return fmt.Sprintf("(%s AND %s)", e.left, e.right) }
+
+type orFilter struct{ left, right Filter }
+
+func (e *orFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	return e.left.Match(p, env) || e.right.Match(p, env)
+}
+func (e *orFilter) Need() gordon.Need { return e.left.Need().Merge(e.right.Need()) }
+func (e *orFilter) String() string    { return fmt.Sprintf("(%s OR %s)", e.left, e.right) }
+
+type notFilter struct{ filter Filter }
+
+func (e *notFilter) Match(p *gordon.PullRequest, env *Env) bool { return !e.filter.Match(p, env) }
+func (e *notFilter) Need() gordon.Need                          { return e.filter.Need() }
+func (e *notFilter) String() string                             { return fmt.Sprintf("NOT %s", e.filter) }
+
+// And returns a filter matching when all of the filters match, nil filters
+// are skipped
+func And(filters ...Filter) Filter {
+	var out Filter
+	for _, e := range filters {
+		switch {
+		case e == nil:
+		case out == nil:
+			out = e
+		default:
+			out = &andFilter{out, e}
+		}
+	}
+	return out
+}
+
+// Or returns a filter matching when any of the filters match
+func Or(left, right Filter) Filter {
+	return &orFilter{left, right}
+}
+
+// Not returns a filter matching when `e` doesn't
+func Not(e Filter) Filter {
+	return &notFilter{e}
+}
+
+// Operators of comparisons
+const (
+	opHas   = ":"
+	opEqual = "="
+	opNot   = "!="
+	opLess  = "<"
+	opLE    = "<="
+	opMore  = ">"
+	opGE    = ">="
+	opMatch = "~"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindText
+	kindList
+	kindPaths
+	kindInt
+	kindDuration
+	kindBool
+)
+
+// attribute is a property of a pull request filters can test
+type attribute struct {
+	kind kind
+	need gordon.Need
+	help string
+
+	str     func(p *gordon.PullRequest, env *Env) string
+	list    func(p *gordon.PullRequest, env *Env) []string
+	number  func(p *gordon.PullRequest, env *Env) int
+	since   func(p *gordon.PullRequest) time.Time
+	boolean func(p *gordon.PullRequest) (value, known bool)
+}
+
+var attributes = map[string]*attribute{
+	"author": {kind: kindString, help: "login of the contributor",
+		str: func(p *gordon.PullRequest, env *Env) string { return p.User.Login }},
+	"assignee": {kind: kindString, help: "login of the assignee, empty when unassigned",
+		str: func(p *gordon.PullRequest, env *Env) string {
+			if p.Assignee == nil {
+				return ""
+			}
+			return p.Assignee.Login
+		}},
+	"base": {kind: kindString, help: "branch the pull request targets, '~' to match a pattern",
+		str: func(p *gordon.PullRequest, env *Env) string { return p.Base.Ref }},
+	"title": {kind: kindText, help: "title, ':' tests if it contains the value",
+		str: func(p *gordon.PullRequest, env *Env) string { return p.Title }},
+	"ci": {kind: kindString, need: gordon.Need{Status: true}, help: "combined status: success, failure, error or pending",
+		str: func(p *gordon.PullRequest, env *Env) string { return p.CIState("") }},
+	"labels": {kind: kindList, help: "names of the labels",
+		list: func(p *gordon.PullRequest, env *Env) []string {
+			labels := make([]string, len(p.Labels))
+			for i, l := range p.Labels {
+				labels[i] = l.Name
+			}
+			return labels
+		}},
+	"files": {kind: kindPaths, need: gordon.Need{Diff: true}, help: "paths of the files touched, ':' tests a directory they are under",
+		list: func(p *gordon.PullRequest, env *Env) []string { return p.Files }},
+	"dirs": {kind: kindPaths, need: gordon.Need{Diff: true}, help: "directories of the files touched, ':' tests a directory they are under",
+		list: func(p *gordon.PullRequest, env *Env) []string {
+			var dirs []string
+			for _, f := range p.Files {
+				if i := strings.LastIndex(f, "/"); i > 0 {
+					dirs = append(dirs, f[:i])
+				}
+			}
+			return dirs
+		}},
+	"reviewers": {kind: kindList, need: gordon.Need{Diff: true, Maintainers: true}, help: "maintainers of the files touched, by username or email",
+		list: func(p *gordon.PullRequest, env *Env) []string {
+			reviewers, err := gordon.ReviewPatch(p.Diff, env.Maintainers)
+			if err != nil {
+				return nil
+			}
+			var out []string
+			for _, fileReviewers := range reviewers {
+				out = append(out, fileReviewers...)
+			}
+			return out
+		}},
+	"lgtm": {kind: kindInt, need: gordon.Need{Comments: true, Diff: true, Maintainers: true}, help: "number of maintainers who approved",
+		number: func(p *gordon.PullRequest, env *Env) int {
+			approval, err := p.LoadApproval(env.Maintainers)
+			if err != nil {
+				return 0
+			}
+			return len(approval.Approvers())
+		}},
+	"size": {kind: kindInt, need: gordon.Need{Full: true}, help: "number of lines added and deleted",
+		number: func(p *gordon.PullRequest, env *Env) int { return p.Additions + p.Deletions }},
+	"changed_files": {kind: kindInt, need: gordon.Need{Full: true}, help: "number of files changed",
+		number: func(p *gordon.PullRequest, env *Env) int { return p.ChangedFiles }},
+	"age": {kind: kindDuration, help: "time since the pull request was opened, e.g. 14d",
+		since: func(p *gordon.PullRequest) time.Time { return p.CreatedAt }},
+	"updated": {kind: kindDuration, help: "time since the last update, e.g. 2w",
+		since: func(p *gordon.PullRequest) time.Time { return p.UpdatedAt }},
+	"mergeable": {kind: kindBool, need: gordon.Need{Mergeable: true}, help: "true when it merges cleanly, neither true nor false if GitHub couldn't compute it in time",
+		boolean: func(p *gordon.PullRequest) (bool, bool) {
+			if p.Mergeable == nil {
+				return false, false
+			}
+			return *p.Mergeable, true
+		}},
+	"mergeable_state": {kind: kindString, need: gordon.Need{Mergeable: true}, help: "clean, dirty, blocked, behind, unstable or unknown",
+		str: func(p *gordon.PullRequest, env *Env) string { return mergeableState(p) }},
+	"draft": {kind: kindBool, help: "true for draft pull requests",
+		boolean: func(p *gordon.PullRequest) (bool, bool) { return p.Draft, true }},
+	"fork": {kind: kindBool, help: "true when the branch lives in a fork",
+		boolean: func(p *gordon.PullRequest) (bool, bool) { return p.FromFork(), true }},
+	"maintainer_can_modify": {kind: kindBool, help: "true when the maintainers can push to the branch",
+		boolean: func(p *gordon.PullRequest) (bool, bool) { return p.MaintainerCanModify, true }},
+}
+
+// mergeableState returns the mergeable state of a pull request, unknown when
+// GitHub didn't compute it
+func mergeableState(p *gordon.PullRequest) string {
+	if p.Mergeable == nil || p.MergeableState == "" {
+		return gordon.MergeableUnknown
+	}
+	return p.MergeableState
+}
+
+// attribute aliases
+func init() {
+	attributes["user"] = attributes["author"]
+	attributes["lines"] = attributes["size"]
+	attributes["label"] = attributes["labels"]
+	attributes["file"] = attributes["files"]
+	attributes["dir"] = attributes["dirs"]
+	attributes["reviewer"] = attributes["reviewers"]
+}
+
+// comparison tests one attribute of the pull request against a value
+type comparison struct {
+	name  string
+	attr  *attribute
+	op    string
+	value string
+
+	re       *regexp.Regexp
+	number   int
+	duration time.Duration
+	boolean  bool
+}
+
+// Compare returns the filter comparing the attribute `name` to `value`
+func Compare(name, op, value string) (Filter, error) {
+	attr, exists := attributes[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("unknown attribute %q", name)
+	}
+	c := &comparison{name: strings.ToLower(name), attr: attr, op: op, value: value}
+
+	var err error
+	switch attr.kind {
+	case kindString, kindText, kindList, kindPaths:
+		switch op {
+		case opHas, opEqual, opNot:
+		case opMatch:
+			if c.re, err = regexp.Compile(value); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+		default:
+			return nil, fmt.Errorf("%s can't be compared with %s", name, op)
+		}
+	case kindInt:
+		if op == opMatch {
+			return nil, fmt.Errorf("%s can't be compared with %s", name, op)
+		}
+		if c.number, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%s expects a number, got %q", name, value)
+		}
+	case kindDuration:
+		if op == opMatch {
+			return nil, fmt.Errorf("%s can't be compared with %s", name, op)
+		}
+		if c.duration, err = gordon.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	case kindBool:
+		switch op {
+		case opHas, opEqual, opNot:
+		default:
+			return nil, fmt.Errorf("%s can't be compared with %s", name, op)
+		}
+		if c.boolean, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("%s expects true or false, got %q", name, value)
+		}
+	}
+	return c, nil
+}
+
+func (c *comparison) Need() gordon.Need { return c.attr.need }
+
+func (c *comparison) String() string {
+	return fmt.Sprintf("%s%s%s", c.name, c.op, strconv.Quote(c.value))
+}
+
+func (c *comparison) Match(p *gordon.PullRequest, env *Env) bool {
+	switch c.attr.kind {
+	case kindString, kindText:
+		return c.matchString(c.attr.str(p, env))
+	case kindList, kindPaths:
+		values := c.attr.list(p, env)
+		if c.op == opNot {
+			for _, v := range values {
+				if c.equal(v) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, v := range values {
+			if c.matchString(v) {
+				return true
+			}
+		}
+		return false
+	case kindInt:
+		return compareInts(int64(c.attr.number(p, env)), c.op, int64(c.number))
+	case kindDuration:
+		return compareInts(int64(env.Now.Sub(c.attr.since(p))), c.op, int64(c.duration))
+	case kindBool:
+		value, known := c.attr.boolean(p)
+		if !known {
+			return false
+		}
+		if c.op == opNot {
+			return value != c.boolean
+		}
+		return value == c.boolean
+	}
+	return false
+}
+
+func (c *comparison) matchString(s string) bool {
+	switch c.op {
+	case opMatch:
+		return c.re.MatchString(s)
+	case opNot:
+		return !c.equal(s)
+	case opHas:
+		switch c.attr.kind {
+		case kindText:
+			return strings.Contains(strings.ToLower(s), strings.ToLower(c.value))
+		case kindPaths:
+			// a prefix of whole directories, with or without their
+			// trailing slash: pkg matches pkg/term but not pkgs
+			return c.equal(s) || strings.HasPrefix(s, strings.TrimSuffix(c.value, "/")+"/")
+		}
+	}
+	return c.equal(s)
+}
+
+func (c *comparison) equal(s string) bool {
+	if c.attr.kind == kindPaths {
+		// dir=pkg and dir=pkg/ are the same
+		return strings.TrimSuffix(s, "/") == strings.TrimSuffix(c.value, "/")
+	}
+	return strings.EqualFold(s, c.value)
+}
+
+func compareInts(x int64, op string, y int64) bool {
+	switch op {
+	case opLess:
+		return x < y
+	case opLE:
+		return x <= y
+	case opMore:
+		return x > y
+	case opGE:
+		return x >= y
+	case opNot:
+		return x != y
+	}
+	return x == y
+}
@@ -3,215 +3,537 @@ package filters
 import (
 	"fmt"
 	"github.com/docker/gordon/pkg/gordon"
-	"io/ioutil"
-	"net/http"
-	"sort"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/urfave/cli"
 	gh "github.com/crosbymichael/octokat"
 )
 
-func FilterPullRequests(c *cli.Context, prs []*gh.PullRequest) ([]*gh.PullRequest, error) {
-	var (
-		yesterday  = time.Now().Add(-24 * time.Hour)
-		out        = filteredPullRequests{} //[]*gh.PullRequest{}
-		email, err = gordon.GetMaintainerManagerEmail()
-		chPrs      = make(chan *gh.PullRequest)
-	)
-	if err != nil {
-		return nil, err
+// PullRequestFilter holds the options selecting pull requests, the zero value
+// selects them all
+type PullRequestFilter struct {
+	// New selects the pull requests opened in the last 24 hours
+	New bool
+	// User selects the pull requests opened by this login
+	User string
+	// Cleanup selects the pull requests whose title starts with "cleanup"
+	Cleanup bool
+	// Maintainer selects the pull requests touching the files of this
+	// maintainer, by username or email
+	Maintainer string
+	// Dir selects the pull requests touching a file under this directory
+	Dir string
+	// Extension selects the pull requests touching a file with this extension
+	Extension string
+	// Unassigned selects the pull requests assigned to nobody, Assigned the
+	// ones assigned to this login
+	Unassigned bool
+	Assigned   string
+	// NoMerge selects the pull requests that can't be merged cleanly
+	NoMerge bool
+	// MergeableStates selects the pull requests in any of these mergeable
+	// states, see gordon.MergeableStates
+	MergeableStates []string
+	// Labels selects the pull requests with all of these labels, NoLabels
+	// the ones with none of those
+	Labels   []string
+	NoLabels []string
+	// CI selects the pull requests whose status is success, failure or
+	// pending. With CIContext, only the status reported by this context is
+	// considered, and alone it selects the pull requests it reported on.
+	CI        string
+	CIContext string
+	// CreatedAfter, CreatedBefore and UpdatedBefore select the pull requests
+	// by date, the zero time selects them all
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedBefore time.Time
+	// OlderThan selects the pull requests opened longer ago than this
+	OlderThan time.Duration
+	// IdleSinceAuthor selects the pull requests waiting on their author: a
+	// maintainer took part after the author, who has been idle for this long.
+	// IdleSinceMaintainer selects the ones waiting on the maintainers: the
+	// author took part last, and no maintainer did for this long.
+	IdleSinceAuthor     time.Duration
+	IdleSinceMaintainer time.Duration
+	// Base selects the pull requests targeting a branch, or the branches
+	// matching a pattern such as "release-*"
+	Base string
+	// Draft selects the drafts, NoDraft the pull requests ready for review
+	Draft   bool
+	NoDraft bool
+	// FromFork selects the pull requests from a fork, SameRepo the ones
+	// from a branch of the repository itself
+	FromFork bool
+	SameRepo bool
+	// MaintainerCanModify selects the pull requests the maintainers can push to
+	MaintainerCanModify bool
+	// MinLines and MaxLines select the pull requests by the number of lines
+	// they add and delete, MaxFiles by the number of files they change.
+	// Zero selects them all.
+	MinLines int
+	MaxLines int
+	MaxFiles int
+	// Expr is a filter expression, see Parse
+	Expr string
+	// LGTM computes the approval of the pull requests selected
+	LGTM bool
+	// Sort is the key the pull requests selected are sorted by, see
+	// PullRequestSorts, Reverse reverses their order
+	Sort    string
+	Reverse bool
+}
+
+// Compile returns the filter selecting the pull requests, nil when all of
+// them are selected
+func (o PullRequestFilter) Compile() (Filter, error) {
+	type comparison struct{ name, op, value string }
+	var comparisons []comparison
+
+	if o.New {
+		comparisons = append(comparisons, comparison{"age", opLess, "24h"})
+	}
+	if o.User != "" {
+		comparisons = append(comparisons, comparison{"author", opEqual, o.User})
+	}
+	if o.Cleanup {
+		comparisons = append(comparisons, comparison{"title", opMatch, "(?i)^cleanup"})
+	}
+	if o.Maintainer != "" {
+		comparisons = append(comparisons, comparison{"reviewers", opEqual, o.Maintainer})
+	}
+	if o.Dir != "" {
+		comparisons = append(comparisons, comparison{"files", opHas, o.Dir})
+	}
+	if o.Extension != "" {
+		comparisons = append(comparisons, comparison{"files", opMatch, regexp.QuoteMeta(o.Extension) + "$"})
+	}
+	if o.Unassigned {
+		comparisons = append(comparisons, comparison{"assignee", opEqual, ""})
+	} else if o.Assigned != "" {
+		comparisons = append(comparisons, comparison{"assignee", opEqual, o.Assigned})
+	}
+	if o.NoMerge {
+		comparisons = append(comparisons, comparison{"mergeable", opEqual, "false"})
+	}
+	for _, label := range o.Labels {
+		comparisons = append(comparisons, comparison{"labels", opEqual, label})
+	}
+	for _, label := range o.NoLabels {
+		comparisons = append(comparisons, comparison{"labels", opNot, label})
+	}
+	if o.Draft {
+		comparisons = append(comparisons, comparison{"draft", opEqual, "true"})
+	}
+	if o.NoDraft {
+		comparisons = append(comparisons, comparison{"draft", opEqual, "false"})
+	}
+	if o.FromFork {
+		comparisons = append(comparisons, comparison{"fork", opEqual, "true"})
+	}
+	if o.SameRepo {
+		comparisons = append(comparisons, comparison{"fork", opEqual, "false"})
+	}
+	if o.MaintainerCanModify {
+		comparisons = append(comparisons, comparison{"maintainer_can_modify", opEqual, "true"})
+	}
+	if o.MinLines > 0 {
+		comparisons = append(comparisons, comparison{"size", opGE, strconv.Itoa(o.MinLines)})
+	}
+	if o.MaxLines > 0 {
+		comparisons = append(comparisons, comparison{"size", opLE, strconv.Itoa(o.MaxLines)})
+	}
+	if o.MaxFiles > 0 {
+		comparisons = append(comparisons, comparison{"changed_files", opLE, strconv.Itoa(o.MaxFiles)})
+	}
+	if o.OlderThan > 0 {
+		comparisons = append(comparisons, comparison{"age", opMore, o.OlderThan.String()})
 	}
 
-	for _, pr := range prs {
-		go func(pr *gh.PullRequest) {
-			if c.Bool("new") && !pr.CreatedAt.After(yesterday) {
-				chPrs <- nil
-				return
+	var filters []Filter
+	for _, c := range comparisons {
+		f, err := Compare(c.name, c.op, c.value)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(o.MergeableStates) > 0 {
+		var states Filter
+		for _, state := range o.MergeableStates {
+			if !isMergeableState(state) {
+				return nil, fmt.Errorf("invalid mergeable state %q, expected one of %s", state, strings.Join(gordon.MergeableStates, ", "))
 			}
-
-			if user := c.String("user"); user != "" {
-				if pr.User.Login != user {
-					chPrs <- nil
-					return
-				}
+			f, err := Compare("mergeable_state", opEqual, state)
+			if err != nil {
+				return nil, err
 			}
-
-			if c.Bool("cleanup") {
-				if !strings.HasPrefix(strings.ToLower(pr.Title), "cleanup") {
-					chPrs <- nil
-					return
-				}
+			if states == nil {
+				states = f
+			} else {
+				states = Or(states, f)
 			}
+		}
+		filters = append(filters, states)
+	}
+	if o.Base != "" {
+		if _, err := path.Match(o.Base, ""); err != nil {
+			return nil, fmt.Errorf("invalid base %q: %v", o.Base, err)
+		}
+		filters = append(filters, &baseFilter{o.Base})
+	}
+	if o.CI != "" || o.CIContext != "" {
+		switch o.CI {
+		case "", gordon.CISuccess, gordon.CIFailure, gordon.CIPending:
+		default:
+			return nil, fmt.Errorf("invalid ci state %q, expected success, failure or pending", o.CI)
+		}
+		filters = append(filters, &ciFilter{state: o.CI, context: o.CIContext})
+	}
+	if !o.CreatedAfter.IsZero() {
+		filters = append(filters, &dateFilter{"created", createdAt, true, o.CreatedAfter})
+	}
+	if !o.CreatedBefore.IsZero() {
+		filters = append(filters, &dateFilter{"created", createdAt, false, o.CreatedBefore})
+	}
+	if !o.UpdatedBefore.IsZero() {
+		filters = append(filters, &dateFilter{"updated", updatedAt, false, o.UpdatedBefore})
+	}
+	if o.IdleSinceAuthor > 0 {
+		filters = append(filters, &idleFilter{maintainers: false, idle: o.IdleSinceAuthor})
+	}
+	if o.IdleSinceMaintainer > 0 {
+		filters = append(filters, &idleFilter{maintainers: true, idle: o.IdleSinceMaintainer})
+	}
+	if o.Expr != "" {
+		f, err := Parse(o.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %v", err)
+		}
+		filters = append(filters, f)
+	}
+	return And(filters...), nil
+}
 
-			maintainer := c.String("maintainer")
-			if maintainer == "" && c.Bool("mine") {
-				maintainer = email
-			}
-			dir := c.String("dir")
-			extension := c.String("extension")
+// ciFilter selects the pull requests by the state of their status
+type ciFilter struct {
+	state   string
+	context string
+}
 
-			var diff []byte
+func (f *ciFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	state := p.CIState(f.context)
+	switch f.state {
+	case "":
+		return state != ""
+	case gordon.CIFailure:
+		return state == gordon.CIFailure || state == gordon.CIError
+	}
+	return state == f.state
+}
 
-			if maintainer != "" || dir != "" || extension != "" {
-				diffResp, err := http.Get(pr.DiffURL)
-				if err != nil {
-					chPrs <- nil
-					return
-				}
+func (f *ciFilter) Need() gordon.Need { return gordon.Need{Status: true} }
 
-				diff, err = ioutil.ReadAll(diffResp.Body)
-				if err != nil {
-					chPrs <- nil
-					return
-				}
+func (f *ciFilter) String() string {
+	if f.context == "" {
+		return fmt.Sprintf("ci=%q", f.state)
+	}
+	return fmt.Sprintf("ci[%s]=%q", f.context, f.state)
+}
 
-				diffResp.Body.Close()
-			}
+func createdAt(p *gordon.PullRequest) time.Time { return p.CreatedAt }
+func updatedAt(p *gordon.PullRequest) time.Time { return p.UpdatedAt }
 
-			if dir != "" {
-				dirs, err := gordon.GetDirsForPR(diff, dir)
-				if err != nil {
-					chPrs <- nil
-					return
-				}
+// dateFilter selects the pull requests by one of their dates
+type dateFilter struct {
+	name  string
+	date  func(p *gordon.PullRequest) time.Time
+	after bool
+	t     time.Time
+}
 
-				if len(dirs) == 0 {
-					chPrs <- nil
-					return
-				}
-			}
+func (f *dateFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	if f.after {
+		return f.date(p).After(f.t)
+	}
+	return f.date(p).Before(f.t)
+}
 
-			if extension != "" {
-				files, err := gordon.GetFileExtensionsForPR(diff, extension)
-				if err != nil {
-					chPrs <- nil
-					return
-				}
+func (f *dateFilter) Need() gordon.Need { return gordon.Need{} }
 
-				if len(files) == 0 {
-					chPrs <- nil
-					return
-				}
-			}
+func (f *dateFilter) String() string {
+	if f.after {
+		return fmt.Sprintf("%s>%s", f.name, f.t.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s<%s", f.name, f.t.Format(time.RFC3339))
+}
 
-			if maintainer != "" {
-				var found bool
-				reviewers, err := gordon.GetReviewersForPR(diff, true)
-				if err != nil {
-					chPrs <- nil
-					return
-				}
-				for file := range reviewers {
-					for _, reviewer := range reviewers[file] {
-						if reviewer == maintainer {
-							found = true
-						}
-					}
-				}
-				if !found {
-					chPrs <- nil
-					return
-				}
+// idleFilter selects the pull requests waiting on the maintainers, or on
+// their author, for some time
+type idleFilter struct {
+	maintainers bool
+	idle        time.Duration
+}
 
-			}
+func (f *idleFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	author, maintainer := p.LastActivity(env.IsMaintainer)
+	if f.maintainers {
+		if maintainer.After(author) {
+			return false
+		}
+		if maintainer.IsZero() {
+			maintainer = p.CreatedAt
+		}
+		return env.Now.Sub(maintainer) >= f.idle
+	}
+	return maintainer.After(author) && env.Now.Sub(author) >= f.idle
+}
 
-			if c.Bool("unassigned") && pr.Assignee != nil {
-				chPrs <- nil
-				return
-			} else if assigned := c.String("assigned"); assigned != "" && (pr.Assignee == nil || pr.Assignee.Login != assigned) {
-				chPrs <- nil
-				return
-			}
+func (f *idleFilter) Need() gordon.Need { return gordon.Need{Comments: true, Maintainers: true} }
 
-			if c.Bool("lgtm") {
-				pr.ReviewComments = 0
-				maintainersOccurrence := map[string]bool{}
-				for _, comment := range pr.CommentsBody {
-					// We should check it this LGTM is by a user in
-					// the maintainers file
-					userName := comment.User.Login
-					if strings.Contains(comment.Body, "LGTM") && !maintainersOccurrence[userName] {
-						maintainersOccurrence[userName] = true
-						pr.ReviewComments += 1
-					}
-				}
-			}
+func (f *idleFilter) String() string {
+	if f.maintainers {
+		return fmt.Sprintf("idle-since-maintainer>=%s", f.idle)
+	}
+	return fmt.Sprintf("idle-since-author>=%s", f.idle)
+}
+
+func isMergeableState(state string) bool {
+	for _, s := range gordon.MergeableStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// APIBase returns the base branch GitHub can select the pull requests by,
+// empty when Base is a pattern
+func (o PullRequestFilter) APIBase() string {
+	if strings.ContainsAny(o.Base, `*?[\`) {
+		return ""
+	}
+	return o.Base
+}
+
+// baseFilter selects the pull requests targeting the branches matching a pattern
+type baseFilter struct {
+	pattern string
+}
+
+func (f *baseFilter) Match(p *gordon.PullRequest, env *Env) bool {
+	matched, _ := path.Match(f.pattern, p.Base.Ref)
+	return matched
+}
+
+func (f *baseFilter) Need() gordon.Need { return gordon.Need{} }
+func (f *baseFilter) String() string    { return fmt.Sprintf("base=%q", f.pattern) }
+
+// Need returns the data to fetch for the pull requests before filtering and
+// sorting them
+func (o PullRequestFilter) Need(filter Filter) gordon.Need {
+	var need gordon.Need
+	if filter != nil {
+		need = filter.Need()
+	}
+	if o.approve() {
+		need = need.Merge(gordon.Need{Comments: true, Diff: true, Maintainers: true})
+	}
+	key, _ := sortKey(o.Sort, PullRequestSorts)
+	return need.Merge(sortNeed(key))
+}
+
+// approve returns true if the approvals of the pull requests are needed
+func (o PullRequestFilter) approve() bool {
+	key, _ := sortKey(o.Sort, PullRequestSorts)
+	return o.LGTM || key == SortLGTM
+}
+
+// APISort returns the sort to list the pull requests with from the API, which
+// only matters to what is listed first as they are sorted once filtered
+func (o PullRequestFilter) APISort() string {
+	if key, _ := sortKey(o.Sort, PullRequestSorts); key == SortCreated {
+		return SortCreated
+	}
+	return SortUpdated
+}
+
+// Fetcher gets what the filters need to select the pull requests, such as
+// *gordon.MaintainerManager
+type Fetcher interface {
+	// FetchPullRequests gets the data selected by `need` for each pull
+	// request, leaving out the ones that fail
+	FetchPullRequests(prs []*gordon.PullRequest, need gordon.Need) []*gordon.PullRequest
+	// Maintainers returns the maintainers of the files of the repository
+	Maintainers() (map[string][]string, error)
+}
 
-			if c.Bool("no-merge") && *pr.Mergeable {
-				chPrs <- nil
+// FilterPullRequests fetches through `f` the data the options need, and
+// returns the pull requests they select sorted by the key of the options
+func FilterPullRequests(f Fetcher, prs []*gordon.PullRequest, o PullRequestFilter) ([]*gordon.PullRequest, error) {
+	filter, err := o.Compile()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sortKey(o.Sort, PullRequestSorts); err != nil {
+		return nil, err
+	}
+	need := o.Need(filter)
+	var maintainers map[string][]string
+	if need.Maintainers {
+		if maintainers, err = f.Maintainers(); err != nil {
+			return nil, err
+		}
+	}
+	if need.Any() {
+		prs = f.FetchPullRequests(prs, need)
+	}
+	prs = Apply(prs, filter, NewEnv(maintainers), o.approve())
+	return prs, SortPullRequests(prs, o.Sort, o.Reverse)
+}
+
+// Apply returns the pull requests matching `filter` in `env`, or all of them
+// when it is nil, in the same order. The data the filter needs must have been
+// fetched. With `lgtm`, the approval of each pull request is computed too,
+// counting the maintainers of `env`.
+func Apply(prs []*gordon.PullRequest, filter Filter, env *Env, lgtm bool) []*gordon.PullRequest {
+	var (
+		out     = []*gordon.PullRequest{}
+		matches = make([]bool, len(prs))
+		wg      sync.WaitGroup
+	)
+
+	for i, pr := range prs {
+		wg.Add(1)
+		go func(i int, pr *gordon.PullRequest) {
+			defer wg.Done()
+			if filter != nil && !filter.Match(pr, env) {
 				return
 			}
-			chPrs <- pr
-		}(pr)
+			if lgtm {
+				// Only count the LGTMs of the maintainers of the files
+				// touched by the pull request
+				if _, err := pr.LoadApproval(env.Maintainers); err != nil {
+					return
+				}
+			}
+			matches[i] = true
+		}(i, pr)
 	}
-	for i := 0; i < len(prs); i++ {
-		if pr := <-chPrs; pr != nil {
+	wg.Wait()
+
+	for i, pr := range prs {
+		if matches[i] {
 			out = append(out, pr)
 		}
 	}
-	sort.Sort(out)
-	return out, nil
+	return out
 }
 
-type filteredPullRequests []*gh.PullRequest
+// IssueFilter holds the options selecting issues, the zero value selects them all
+type IssueFilter struct {
+	// New selects the issues opened in the last 24 hours
+	New bool
+	// Milestone selects the issues of this milestone
+	Milestone string
+	// Votes selects the issues with at least this many +1 comments
+	Votes int
+	// Proposals selects the issues whose title starts with "Proposal"
+	Proposals bool
+	// Labels selects the issues with all of these labels, NoLabels the ones
+	// with none of those
+	Labels   []string
+	NoLabels []string
+	// CreatedAfter, CreatedBefore and UpdatedBefore select the issues by
+	// date, the zero time selects them all
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedBefore time.Time
+	// OlderThan selects the issues opened longer ago than this
+	OlderThan time.Duration
+	// Sort is the key the issues selected are sorted by, see IssueSorts,
+	// Reverse reverses their order
+	Sort    string
+	Reverse bool
+}
 
-func (r filteredPullRequests) Len() int      { return len(r) }
-func (r filteredPullRequests) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
-func (r filteredPullRequests) Less(i, j int) bool {
-	return r[j].UpdatedAt.After(r[i].UpdatedAt)
+// VoteLoader counts the votes of the issues, such as *gordon.MaintainerManager
+type VoteLoader interface {
+	LoadVotes(issues []*gordon.Issue) error
 }
 
-func FilterIssues(c *cli.Context, issues []*gh.Issue) ([]*gh.Issue, error) {
+// FilterIssues returns the issues selected by the options sorted by their
+// key, counting the votes of the issues left through `v` when needed
+func FilterIssues(v VoteLoader, issues []*gordon.Issue, o IssueFilter) ([]*gordon.Issue, error) {
 	var (
-		yesterday      = time.Now().Add(-24 * time.Hour)
-		out            = []*gh.Issue{}
-		client         = gh.NewClient()
-		org, name, err = gordon.GetRemoteUrl(c.String("remote"))
+		yesterday = time.Now().Add(-24 * time.Hour)
+		out       = []*gordon.Issue{}
 	)
-	if err != nil {
-		return nil, err
-	}
-	t, err := gordon.NewMaintainerManager(client, org, name)
-	if err != nil {
+	if _, err := sortKey(o.Sort, IssueSorts); err != nil {
 		return nil, err
 	}
 
 	for _, issue := range issues {
-		fmt.Printf(".")
+		if o.New && !issue.CreatedAt.After(yesterday) {
+			continue
+		}
 
-		if c.Bool("new") && !issue.CreatedAt.After(yesterday) {
+		if o.Milestone != "" && issue.Milestone.Title != o.Milestone {
 			continue
 		}
 
-		if milestone := c.String("milestone"); milestone != "" && issue.Milestone.Title != milestone {
+		if !o.CreatedAfter.IsZero() && !issue.CreatedAt.After(o.CreatedAfter) ||
+			!o.CreatedBefore.IsZero() && !issue.CreatedAt.Before(o.CreatedBefore) ||
+			!o.UpdatedBefore.IsZero() && !issue.UpdatedAt.Before(o.UpdatedBefore) ||
+			o.OlderThan > 0 && time.Since(issue.CreatedAt) <= o.OlderThan {
 			continue
 		}
 
-		if numVotes := c.Int("votes"); numVotes > 0 {
-			comments, err := t.GetComments(strconv.Itoa(issue.Number))
-			if err != nil {
-				return nil, err
-			}
-			issue.Comments = 0
-			for _, comment := range comments {
-				if strings.Contains(comment.Body, "+1") {
-					issue.Comments += 1
-				}
-			}
-			if issue.Comments < numVotes {
-				continue
-			}
+		if o.Proposals && !strings.HasPrefix(issue.Title, "Proposal") {
+			continue
 		}
 
-		if c.Bool("proposals") && !strings.HasPrefix(issue.Title, "Proposal") {
+		if !hasLabels(issue.Issue, o.Labels, true) || !hasLabels(issue.Issue, o.NoLabels, false) {
 			continue
 		}
 
 		out = append(out, issue)
 	}
-	return out, nil
 
+	if key, _ := sortKey(o.Sort, IssueSorts); o.Votes > 0 || key == SortVotes {
+		// votes are counted last, only the issues left may need their comments
+		if err := v.LoadVotes(out); err != nil {
+			return nil, err
+		}
+		voted := []*gordon.Issue{}
+		for _, issue := range out {
+			if issue.Votes >= o.Votes {
+				voted = append(voted, issue)
+			}
+		}
+		out = voted
+	}
+	return out, SortIssues(out, o.Sort, o.Reverse)
+}
+
+// hasLabels returns true if the issue has all of the labels when `want` is
+// true, or none of them when it is false
+func hasLabels(issue *gh.Issue, labels []string, want bool) bool {
+	for _, name := range labels {
+		found := false
+		for _, l := range issue.Labels {
+			if strings.EqualFold(l.Name, name) {
+				found = true
+				break
+			}
+		}
+		if found != want {
+			return false
+		}
+	}
+	return true
 }
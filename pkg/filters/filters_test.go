@@ -0,0 +1,258 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+func testPullRequests(now time.Time) []*gordon.PullRequest {
+	repo := gh.Repository{FullName: "docker/docker"}
+	fork := gh.Repository{FullName: "alice/docker"}
+	return []*gordon.PullRequest{
+		{
+			PullRequest: &gh.PullRequest{
+				Number:       1,
+				Title:        "Cleanup the daemon",
+				User:         gh.User{Login: "alice"},
+				CreatedAt:    now.Add(-30 * 24 * time.Hour),
+				Head:         gh.Commit{Repo: fork},
+				Base:         gh.Commit{Ref: "master", Repo: repo},
+				Additions:    400,
+				Deletions:    100,
+				ChangedFiles: 12,
+			},
+			Labels: []gh.Label{{Name: "bug"}},
+			Draft:  true,
+			Files:  []string{"daemon/daemon.go", "pkg/term/term.go"},
+		},
+		{
+			PullRequest: &gh.PullRequest{
+				Number:       2,
+				Title:        "Fix the docs",
+				User:         gh.User{Login: "bob"},
+				CreatedAt:    now.Add(-time.Hour),
+				Head:         gh.Commit{Repo: repo},
+				Base:         gh.Commit{Ref: "release-1.0", Repo: repo},
+				Additions:    8,
+				Deletions:    2,
+				ChangedFiles: 1,
+			},
+			Files: []string{"docs/README.md"},
+		},
+		{
+			PullRequest: &gh.PullRequest{
+				Number:       3,
+				Title:        "Add a flag",
+				User:         gh.User{Login: "carol"},
+				CreatedAt:    now.Add(-10 * 24 * time.Hour),
+				Head:         gh.Commit{Repo: fork},
+				Base:         gh.Commit{Ref: "master", Repo: repo},
+				Additions:    150,
+				Deletions:    50,
+				ChangedFiles: 3,
+			},
+			Labels:              []gh.Label{{Name: "enhancement"}, {Name: "wip"}},
+			MaintainerCanModify: true,
+			Files:               []string{"cli/flags.go", "docs/cli.md"},
+		},
+	}
+}
+
+func numbers(prs []*gordon.PullRequest) string {
+	var out []int
+	for _, p := range prs {
+		out = append(out, p.Number)
+	}
+	return fmt.Sprint(out)
+}
+
+func TestCompileApply(t *testing.T) {
+	for _, test := range []struct {
+		options  PullRequestFilter
+		expected string
+	}{
+		{PullRequestFilter{}, "[1 2 3]"},
+		{PullRequestFilter{New: true}, "[2]"},
+		{PullRequestFilter{User: "Bob"}, "[2]"},
+		{PullRequestFilter{Cleanup: true}, "[1]"},
+		{PullRequestFilter{Dir: "docs"}, "[2 3]"},
+		{PullRequestFilter{Extension: ".md"}, "[2 3]"},
+		{PullRequestFilter{Labels: []string{"wip"}}, "[3]"},
+		{PullRequestFilter{NoLabels: []string{"bug"}}, "[2 3]"},
+		{PullRequestFilter{Draft: true}, "[1]"},
+		{PullRequestFilter{NoDraft: true}, "[2 3]"},
+		{PullRequestFilter{FromFork: true}, "[1 3]"},
+		{PullRequestFilter{SameRepo: true}, "[2]"},
+		{PullRequestFilter{MaintainerCanModify: true}, "[3]"},
+		{PullRequestFilter{MinLines: 200}, "[1 3]"},
+		{PullRequestFilter{MaxLines: 200}, "[2 3]"},
+		{PullRequestFilter{MinLines: 100, MaxLines: 400}, "[3]"},
+		{PullRequestFilter{MaxFiles: 3}, "[2 3]"},
+		{PullRequestFilter{OlderThan: 7 * 24 * time.Hour}, "[1 3]"},
+		{PullRequestFilter{OlderThan: 20 * 24 * time.Hour}, "[1]"},
+		{PullRequestFilter{Base: "release-*"}, "[2]"},
+		{PullRequestFilter{CreatedAfter: time.Now().Add(-15 * 24 * time.Hour)}, "[2 3]"},
+		// the options and the expression are all applied
+		{PullRequestFilter{FromFork: true, NoDraft: true}, "[3]"},
+		{PullRequestFilter{MinLines: 10, OlderThan: 5 * 24 * time.Hour, Draft: true}, "[1]"},
+		{PullRequestFilter{FromFork: true, Expr: "label:bug OR size<=10"}, "[1]"},
+		{PullRequestFilter{Expr: "NOT fork AND age<1d"}, "[2]"},
+		{PullRequestFilter{Expr: "dir=docs OR title~^Cleanup"}, "[1 2 3]"},
+		{PullRequestFilter{Expr: "dir=pkg/term/"}, "[1]"},
+		{PullRequestFilter{Expr: "dir:pkg"}, "[1]"},
+		{PullRequestFilter{Expr: "dir:daemon/"}, "[1]"},
+		{PullRequestFilter{Expr: "dir!=docs"}, "[1]"},
+		{PullRequestFilter{Expr: "size=200"}, "[3]"},
+		{PullRequestFilter{Expr: "size!=200"}, "[1 2]"},
+		{PullRequestFilter{Expr: "changed_files<3"}, "[2]"},
+		{PullRequestFilter{Expr: "changed_files>3"}, "[1]"},
+		{PullRequestFilter{Expr: "age>=9d AND age<=11d"}, "[3]"},
+		// only whole directories are prefixes
+		{PullRequestFilter{Expr: "dir:pkg/te"}, "[]"},
+		{PullRequestFilter{Expr: "files:daemon/daemon"}, "[]"},
+		{PullRequestFilter{Expr: "files:docs/README.md"}, "[2]"},
+		{PullRequestFilter{Expr: "dir:cli OR files:cli/flags"}, "[3]"},
+	} {
+		filter, err := test.options.Compile()
+		if err != nil {
+			t.Errorf("%+v: %v", test.options, err)
+			continue
+		}
+		prs := Apply(testPullRequests(time.Now()), filter, NewEnv(nil), false)
+		if n := numbers(prs); n != test.expected {
+			t.Errorf("%+v (%v): expected %s, got %s", test.options, filter, test.expected, n)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, options := range []PullRequestFilter{
+		{MergeableStates: []string{"clean", "happy"}},
+		{CI: "green"},
+		{Base: "release-["},
+		{Expr: "size>big"},
+		{Expr: "label:bug AND"},
+	} {
+		if _, err := options.Compile(); err == nil {
+			t.Errorf("%+v: expected an error", options)
+		}
+	}
+}
+
+// stubFetcher records what FilterPullRequests asks for
+type stubFetcher struct {
+	need        gordon.Need
+	maintainers int
+	err         error
+}
+
+func (f *stubFetcher) FetchPullRequests(prs []*gordon.PullRequest, need gordon.Need) []*gordon.PullRequest {
+	f.need = need
+	return prs
+}
+
+func (f *stubFetcher) Maintainers() (map[string][]string, error) {
+	f.maintainers++
+	return map[string][]string{"Alice": {"daemon/"}}, f.err
+}
+
+func TestFilterPullRequests(t *testing.T) {
+	for _, test := range []struct {
+		options     PullRequestFilter
+		need        gordon.Need
+		maintainers int
+		expected    string
+	}{
+		{PullRequestFilter{Draft: true}, gordon.Need{}, 0, "[1]"},
+		{PullRequestFilter{MinLines: 200, Sort: "size"}, gordon.Need{Full: true}, 0, "[3 1]"},
+		{PullRequestFilter{Expr: "ci=success"}, gordon.Need{Status: true}, 0, "[]"},
+		{PullRequestFilter{IdleSinceAuthor: time.Hour}, gordon.Need{Comments: true, Maintainers: true}, 1, "[]"},
+	} {
+		f := &stubFetcher{}
+		prs, err := FilterPullRequests(f, testPullRequests(time.Now()), test.options)
+		if err != nil {
+			t.Errorf("%+v: %v", test.options, err)
+			continue
+		}
+		if f.need != test.need || f.maintainers != test.maintainers {
+			t.Errorf("%+v: expected to fetch %+v and the maintainers %d times, got %+v and %d", test.options, test.need, test.maintainers, f.need, f.maintainers)
+		}
+		if n := numbers(prs); n != test.expected {
+			t.Errorf("%+v: expected %s, got %s", test.options, test.expected, n)
+		}
+	}
+
+	f := &stubFetcher{err: errors.New("not a git repository")}
+	if _, err := FilterPullRequests(f, testPullRequests(time.Now()), PullRequestFilter{LGTM: true}); err == nil {
+		t.Errorf("expected the error of the maintainers")
+	}
+}
+
+func TestEnvIsMaintainer(t *testing.T) {
+	env := NewEnv(map[string][]string{"Alice": {"daemon/"}})
+	if !env.IsMaintainer("alice") || env.IsMaintainer("bob") {
+		t.Errorf("expected only alice to be a maintainer")
+	}
+	if NewEnv(nil).IsMaintainer("alice") {
+		t.Errorf("expected no maintainers without MAINTAINERS files")
+	}
+}
+
+// stubVotes gives each issue its number of votes and records the issues
+// counted
+type stubVotes struct{ counted []int }
+
+func (v *stubVotes) LoadVotes(issues []*gordon.Issue) error {
+	for _, issue := range issues {
+		issue.Votes = issue.Number
+		v.counted = append(v.counted, issue.Number)
+	}
+	return nil
+}
+
+func TestFilterIssues(t *testing.T) {
+	now := time.Now()
+	issues := func() []*gordon.Issue {
+		return []*gordon.Issue{
+			{Issue: &gh.Issue{Number: 1, Title: "Proposal: a flag", CreatedAt: now.Add(-48 * time.Hour), Labels: []*gh.Label{{Name: "kind/proposal"}}}},
+			{Issue: &gh.Issue{Number: 2, Title: "It crashes", CreatedAt: now.Add(-time.Hour), Labels: []*gh.Label{{Name: "bug"}}}},
+			{Issue: &gh.Issue{Number: 3, Title: "Proposal: colors", CreatedAt: now.Add(-10 * 24 * time.Hour)}},
+		}
+	}
+	issueNumbers := func(issues []*gordon.Issue) string {
+		var out []int
+		for _, issue := range issues {
+			out = append(out, issue.Number)
+		}
+		return fmt.Sprint(out)
+	}
+	for _, test := range []struct {
+		options  IssueFilter
+		expected string
+		counted  string
+	}{
+		{IssueFilter{Sort: "created"}, "[3 1 2]", "[]"},
+		{IssueFilter{New: true}, "[2]", "[]"},
+		{IssueFilter{Proposals: true, Sort: "created"}, "[3 1]", "[]"},
+		{IssueFilter{NoLabels: []string{"BUG"}, Sort: "created"}, "[3 1]", "[]"},
+		{IssueFilter{OlderThan: 24 * time.Hour, Sort: "created"}, "[3 1]", "[]"},
+		// only the issues left have their votes counted
+		{IssueFilter{Proposals: true, Votes: 2}, "[3]", "[1 3]"},
+		{IssueFilter{Sort: "votes"}, "[3 2 1]", "[1 2 3]"},
+	} {
+		v := &stubVotes{counted: []int{}}
+		out, err := FilterIssues(v, issues(), test.options)
+		if err != nil {
+			t.Errorf("%+v: %v", test.options, err)
+			continue
+		}
+		if n := issueNumbers(out); n != test.expected || fmt.Sprint(v.counted) != test.counted {
+			t.Errorf("%+v: expected %s counting the votes of %s, got %s counting %v", test.options, test.expected, test.counted, n, v.counted)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Parse compiles a filter expression such as
+//
+//	author=crosbymichael AND (label:bug OR title:"fix") AND NOT draft AND age>14d
+//
+// Comparisons are joined by AND, OR and NOT, which can also be written &&,
+// || and !, and grouped with parentheses. Comparisons next to each other are
+// joined by AND. A boolean attribute alone tests if it is true.
+func Parse(input string) (Filter, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("empty filter")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+	}
+	return expr, nil
+}
+
+// Attributes returns the help of every attribute filters can test, by name
+func Attributes() []string {
+	var names []string
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []string
+	for _, name := range names {
+		out = append(out, fmt.Sprintf("%s: %s", name, attributes[name].help))
+	}
+	return out
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return fmt.Sprintf("%q", t.value)
+	}
+	return fmt.Sprintf("'%s'", t.value)
+}
+
+// special are the characters ending a word
+const special = `()"':=!<>~&|`
+
+func lex(input string) ([]token, error) {
+	var (
+		tokens []token
+		runes  = []rune(input)
+	)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var value []rune
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == r || runes[i+1] == '\\') {
+					i++
+				}
+				value = append(value, runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			tokens = append(tokens, token{tokenString, string(value), start})
+			i++
+		case r == '&' || r == '|':
+			if i+1 == len(runes) || runes[i+1] != r {
+				return nil, fmt.Errorf("unexpected '%c' at offset %d", r, i)
+			}
+			kind := tokenAnd
+			if r == '|' {
+				kind = tokenOr
+			}
+			tokens = append(tokens, token{kind, string([]rune{r, r}), i})
+			i += 2
+		case r == '!' && (i+1 == len(runes) || runes[i+1] != '='):
+			tokens = append(tokens, token{tokenNot, "!", i})
+			i++
+		case strings.ContainsRune(":=!<>~", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && strings.ContainsRune("!<>", r) {
+				op += "="
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(special, runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			if n := len(tokens); n > 0 && tokens[n-1].kind == tokenOp && tokens[n-1].pos+len(tokens[n-1].value) == start {
+				// the value of a comparison, e.g. label:or, while
+				// assignee= or is an empty value and an operator
+				tokens = append(tokens, token{tokenWord, word, start})
+				break
+			}
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, token{tokenAnd, word, start})
+			case "OR":
+				tokens = append(tokens, token{tokenOr, word, start})
+			case "NOT":
+				tokens = append(tokens, token{tokenNot, word, start})
+			default:
+				tokens = append(tokens, token{tokenWord, word, start})
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or(left, right)
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenWord, tokenNot, tokenLParen:
+			// implicit AND
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = And(left, right)
+	}
+}
+
+func (p *parser) parseNot() (Filter, error) {
+	if p.peek().kind == tokenNot {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(expr), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at offset %d, got %s", closing.pos, closing)
+		}
+		return expr, nil
+	case tokenWord:
+		if p.peek().kind != tokenOp {
+			// a boolean attribute alone
+			if attr, exists := attributes[strings.ToLower(t.value)]; exists && attr.kind != kindBool {
+				return nil, fmt.Errorf("%s needs to be compared to a value", t.value)
+			}
+			return Compare(t.value, opEqual, "true")
+		}
+		op := p.next()
+		value := p.peek()
+		switch value.kind {
+		case tokenWord, tokenString:
+			p.next()
+		case tokenRParen, tokenEOF, tokenAnd, tokenOr:
+			// an empty value, e.g. assignee=
+			value.value = ""
+		default:
+			return nil, fmt.Errorf("expected a value after %s%s, got %s", t.value, op.value, value)
+		}
+		return Compare(t.value, op.value, value.value)
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", t, t.pos)
+}
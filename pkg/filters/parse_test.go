@@ -0,0 +1,50 @@
+package filters
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{`label:bug`, `label:"bug"`},
+		{`author=alice AND NOT draft`, `(author="alice" AND NOT draft="true")`},
+		{`label:bug OR label:wip age>14d`, `(label:"bug" OR (label:"wip" AND age>"14d"))`},
+		{`label:bug || (fork && !draft)`, `(label:"bug" OR (fork="true" AND NOT draft="true"))`},
+		{`title:"needs review"`, `title:"needs review"`},
+		// a keyword right after an operator is a value
+		{`label:or`, `label:"or"`},
+		{`label=AND and label:not`, `(label="AND" AND label:"not")`},
+		{`NOT label:Or`, `NOT label:"Or"`},
+		// with a space, it's an empty value and an operator
+		{`assignee= or draft`, `(assignee="" OR draft="true")`},
+		{`assignee= AND draft`, `(assignee="" AND draft="true")`},
+	} {
+		f, err := Parse(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if f.String() != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.input, test.expected, f)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`label:bug AND`,
+		`(label:bug`,
+		`label:bug)`,
+		`title:"unterminated`,
+		`label & draft`,
+		`size`,
+		`size>big`,
+		`colour=red`,
+	} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package filters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+// Sort keys of the pull requests and issues. Dates sort the least recent
+// first, counts the largest first except the size which puts the smallest
+// first, the CI puts the successes first and the priority the most urgent.
+const (
+	SortCreated  = "created"
+	SortUpdated  = "updated"
+	SortComments = "comments"
+	SortLGTM     = "lgtm"
+	SortSize     = "size"
+	SortCI       = "ci"
+	SortPriority = "priority"
+	SortVotes    = "votes"
+)
+
+// sortAliases are the sorts of the GitHub API the keys replace, and age
+// which sorts by creation like created
+var sortAliases = map[string]string{
+	"popularity":   SortComments,
+	"long-running": SortCreated,
+	"age":          SortCreated,
+}
+
+// PullRequestSorts lists the keys pull requests can be sorted by
+var PullRequestSorts = []string{SortCreated, SortUpdated, SortComments, SortLGTM, SortSize, SortCI, SortPriority}
+
+// IssueSorts lists the keys issues can be sorted by
+var IssueSorts = []string{SortCreated, SortUpdated, SortComments, SortVotes, SortPriority}
+
+func sortKey(key string, valid []string) (string, error) {
+	if alias, exists := sortAliases[key]; exists {
+		key = alias
+	}
+	if key == "" {
+		return SortUpdated, nil
+	}
+	for _, k := range valid {
+		if k == key {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("invalid sort %q, expected one of %s", key, strings.Join(valid, ", "))
+}
+
+// sortNeed returns the data to fetch for the pull requests to sort them by `key`
+func sortNeed(key string) gordon.Need {
+	switch key {
+	case SortComments, SortSize:
+		return gordon.Need{Full: true}
+	case SortLGTM:
+		return gordon.Need{Comments: true, Diff: true}
+	case SortCI:
+		return gordon.Need{Status: true}
+	}
+	return gordon.Need{}
+}
+
+var ciOrder = map[string]int{
+	gordon.CISuccess: 0,
+	gordon.CIPending: 1,
+	gordon.CIFailure: 2,
+	gordon.CIError:   2,
+	"":               3,
+}
+
+// SortPullRequests sorts the pull requests by `key`, see PullRequestSorts.
+// The data the key needs must have been fetched, and the approvals computed
+// to sort by lgtm.
+func SortPullRequests(prs []*gordon.PullRequest, key string, reverse bool) error {
+	key, err := sortKey(key, PullRequestSorts)
+	if err != nil {
+		return err
+	}
+	var less func(a, b *gordon.PullRequest) bool
+	switch key {
+	case SortCreated:
+		less = func(a, b *gordon.PullRequest) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case SortUpdated:
+		less = func(a, b *gordon.PullRequest) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case SortComments:
+		less = func(a, b *gordon.PullRequest) bool { return a.Comments > b.Comments }
+	case SortLGTM:
+		less = func(a, b *gordon.PullRequest) bool { return approvers(a) > approvers(b) }
+	case SortSize:
+		less = func(a, b *gordon.PullRequest) bool { return a.Additions+a.Deletions < b.Additions+b.Deletions }
+	case SortCI:
+		less = func(a, b *gordon.PullRequest) bool { return ciOrder[a.CIState("")] < ciOrder[b.CIState("")] }
+	case SortPriority:
+		less = func(a, b *gordon.PullRequest) bool { return pullRequestPriority(a) < pullRequestPriority(b) }
+	}
+	sort.Stable(pullRequestSorter{prs, less, reverse})
+	return nil
+}
+
+func approvers(p *gordon.PullRequest) int {
+	if p.Approval == nil {
+		return 0
+	}
+	return len(p.Approval.Approvers())
+}
+
+func pullRequestPriority(p *gordon.PullRequest) int {
+	names := make([]string, len(p.Labels))
+	for i, l := range p.Labels {
+		names[i] = l.Name
+	}
+	return gordon.Priority(names)
+}
+
+type pullRequestSorter struct {
+	prs     []*gordon.PullRequest
+	less    func(a, b *gordon.PullRequest) bool
+	reverse bool
+}
+
+func (s pullRequestSorter) Len() int      { return len(s.prs) }
+func (s pullRequestSorter) Swap(i, j int) { s.prs[i], s.prs[j] = s.prs[j], s.prs[i] }
+func (s pullRequestSorter) Less(i, j int) bool {
+	if s.reverse {
+		return s.less(s.prs[j], s.prs[i])
+	}
+	return s.less(s.prs[i], s.prs[j])
+}
+
+// SortIssues sorts the issues by `key`, see IssueSorts. The votes must have
+// been counted to sort by votes.
+func SortIssues(issues []*gordon.Issue, key string, reverse bool) error {
+	key, err := sortKey(key, IssueSorts)
+	if err != nil {
+		return err
+	}
+	var less func(a, b *gordon.Issue) bool
+	switch key {
+	case SortCreated:
+		less = func(a, b *gordon.Issue) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case SortUpdated:
+		less = func(a, b *gordon.Issue) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case SortComments:
+		less = func(a, b *gordon.Issue) bool { return a.Comments > b.Comments }
+	case SortVotes:
+		less = func(a, b *gordon.Issue) bool { return a.Votes > b.Votes }
+	case SortPriority:
+		less = func(a, b *gordon.Issue) bool { return issuePriority(a.Labels) < issuePriority(b.Labels) }
+	}
+	sort.Stable(issueSorter{issues, less, reverse})
+	return nil
+}
+
+func issuePriority(labels []*gh.Label) int {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return gordon.Priority(names)
+}
+
+type issueSorter struct {
+	issues  []*gordon.Issue
+	less    func(a, b *gordon.Issue) bool
+	reverse bool
+}
+
+func (s issueSorter) Len() int      { return len(s.issues) }
+func (s issueSorter) Swap(i, j int) { s.issues[i], s.issues[j] = s.issues[j], s.issues[i] }
+func (s issueSorter) Less(i, j int) bool {
+	if s.reverse {
+		return s.less(s.issues[j], s.issues[i])
+	}
+	return s.less(s.issues[i], s.issues[j])
+}
@@ -0,0 +1,88 @@
+package filters
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+func TestSortPullRequests(t *testing.T) {
+	for _, test := range []struct {
+		key      string
+		reverse  bool
+		expected string
+	}{
+		// the pull requests were never updated, so they keep their order
+		{"", false, "[1 2 3]"},
+		{"updated", true, "[1 2 3]"},
+		{"created", false, "[1 3 2]"},
+		{"created", true, "[2 3 1]"},
+		{"age", false, "[1 3 2]"},
+		{"long-running", false, "[1 3 2]"},
+		{"size", false, "[2 3 1]"},
+		{"size", true, "[1 3 2]"},
+		// #1 and #2 have as many comments, and stay in the same order
+		// even reversed
+		{"comments", false, "[1 2 3]"},
+		{"popularity", true, "[3 1 2]"},
+		// P1 and priority/high are as urgent
+		{"priority", false, "[1 3 2]"},
+		{"priority", true, "[2 1 3]"},
+	} {
+		prs := testPullRequests(time.Now())
+		prs[0].Comments, prs[1].Comments = 5, 5
+		prs[0].Labels = append(prs[0].Labels, gh.Label{Name: "priority/high"})
+		prs[2].Labels = append(prs[2].Labels, gh.Label{Name: "P1"})
+		if err := SortPullRequests(prs, test.key, test.reverse); err != nil {
+			t.Errorf("%q: %v", test.key, err)
+			continue
+		}
+		if n := numbers(prs); n != test.expected {
+			t.Errorf("%q (reverse %t): expected %s, got %s", test.key, test.reverse, test.expected, n)
+		}
+	}
+
+	if err := SortPullRequests(testPullRequests(time.Now()), "votes", false); err == nil {
+		t.Errorf("expected pull requests not to be sorted by votes")
+	}
+}
+
+func TestSortIssues(t *testing.T) {
+	now := time.Now()
+	for _, test := range []struct {
+		key      string
+		reverse  bool
+		expected string
+	}{
+		{"created", false, "[3 1 2]"},
+		{"age", true, "[2 1 3]"},
+		{"votes", false, "[2 3 1]"},
+		{"votes", true, "[1 2 3]"},
+		{"priority", false, "[2 1 3]"},
+		{"priority", true, "[3 1 2]"},
+	} {
+		issues := []*gordon.Issue{
+			{Issue: &gh.Issue{Number: 1, CreatedAt: now.Add(-48 * time.Hour), Labels: []*gh.Label{{Name: "priority/low"}}}, Votes: 1},
+			{Issue: &gh.Issue{Number: 2, CreatedAt: now.Add(-time.Hour), Labels: []*gh.Label{{Name: "bug"}, {Name: "P0"}}}, Votes: 4},
+			{Issue: &gh.Issue{Number: 3, CreatedAt: now.Add(-10 * 24 * time.Hour)}, Votes: 4},
+		}
+		if err := SortIssues(issues, test.key, test.reverse); err != nil {
+			t.Errorf("%q: %v", test.key, err)
+			continue
+		}
+		var out []int
+		for _, issue := range issues {
+			out = append(out, issue.Number)
+		}
+		if n := fmt.Sprint(out); n != test.expected {
+			t.Errorf("%q (reverse %t): expected %s, got %s", test.key, test.reverse, test.expected, n)
+		}
+	}
+
+	if err := SortIssues(nil, "size", false); err == nil {
+		t.Errorf("expected issues not to be sorted by size")
+	}
+}
@@ -0,0 +1,79 @@
+package gordon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+const apiMediaType = "application/vnd.github.v3+json"
+
+// octokat doesn't know about every endpoint we need (review requests, issue
+// events, ...) so apiRequest talks to the GitHub API directly, reusing the base
+// url and token of the client the manager was created with.
+//
+// `body` is encoded as json when not nil and the response is decoded into `v`
+// when not nil.
+func (m *MaintainerManager) apiRequest(method, p string, query map[string]string, body, v interface{}) error {
+	u, err := url.Parse(m.client.BaseURL)
+	if err != nil {
+		return err
+	}
+	// `p` may hold escaped segments, e.g. the name of a label with a slash
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		return err
+	}
+	u.RawPath = path.Join(u.EscapedPath(), p)
+	u.Path = path.Join(u.Path, unescaped)
+	if len(query) > 0 {
+		values := url.Values{}
+		for k, val := range query {
+			values.Set(k, val)
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	var content io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u.String(), content)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", apiMediaType)
+	req.Header.Set("Content-Type", "application/json")
+	if m.client.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", m.client.Token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return fmt.Errorf("%s %s: %s", method, p, apiErr.Message)
+	}
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,350 @@
+package gordon
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/fkautz/codereview/patch"
+)
+
+// Subsystem is a part of the repository owned by the same MAINTAINERS entry.
+// Path is the target of that entry relative to the top of the repository,
+// "." being the top level MAINTAINERS file.
+type Subsystem struct {
+	Path        string
+	Files       []string
+	Maintainers []string
+	Approvers   []string
+}
+
+// Approved returns true when one of the maintainers of the subsystem has given
+// their LGTM. A subsystem without any maintainer is approved by anyone.
+func (s *Subsystem) Approved() bool {
+	return len(s.Approvers) > 0
+}
+
+// Approval is the state of the reviews of every subsystem touched by a pull request
+type Approval struct {
+	Subsystems []*Subsystem
+	// LGTMs holds everyone who approved, maintainer or not
+	LGTMs []string
+	// Blockers holds the maintainers of the subsystems touched, or the
+	// leads, who rejected the pull request or requested changes. Rejections
+	// holds the others, who don't block it.
+	Blockers   []string
+	Rejections []string
+	// Stale holds everyone whose approval predates the latest push, these
+	// approvals don't count
+	Stale []string
+}
+
+// Approved returns true when every subsystem has been approved
+func (a *Approval) Approved() bool {
+	for _, s := range a.Subsystems {
+		if !s.Approved() {
+			return false
+		}
+	}
+	return len(a.Subsystems) > 0
+}
+
+// Approvers returns the unique logins of the maintainers who approved at least
+// one subsystem
+func (a *Approval) Approvers() []string {
+	var (
+		approvers []string
+		seen      = make(map[string]bool)
+	)
+	for _, s := range a.Subsystems {
+		for _, login := range s.Approvers {
+			if !seen[login] {
+				seen[login] = true
+				approvers = append(approvers, login)
+			}
+		}
+	}
+	sort.Strings(approvers)
+	return approvers
+}
+
+// Pending returns the subsystems still waiting for a maintainer's approval
+func (a *Approval) Pending() []*Subsystem {
+	var pending []*Subsystem
+	for _, s := range a.Subsystems {
+		if !s.Approved() {
+			pending = append(pending, s)
+		}
+	}
+	return pending
+}
+
+// Votes found in comments
+const (
+	NoVote = iota
+	VoteApprove
+	VoteReject
+	VoteWithdraw
+)
+
+var (
+	lgtmRegexp     = regexp.MustCompile(`\bLGTM\b`)
+	notLGTMRegexp  = regexp.MustCompile(`(?i)\bnot\s+LGTM\b`)
+	withdrawRegexp = regexp.MustCompile(`(?i)\bLGTM\b.*\b(withdrawn|withdraw|revoked|retracted)\b|\b(withdraw|withdrawing|revoke|revoking|retract|retracting)\b.*\bLGTM\b`)
+)
+
+// ParseVote returns the vote expressed by a comment: an LGTM approves the pull
+// request, a "NOT LGTM" rejects it and an "LGTM withdrawn" takes back a
+// previous vote. Quoted lines are ignored.
+func ParseVote(body string) int {
+	vote := NoVote
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		switch {
+		case notLGTMRegexp.MatchString(line):
+			return VoteReject
+		case withdrawRegexp.MatchString(line):
+			return VoteWithdraw
+		case lgtmRegexp.MatchString(line):
+			vote = VoteApprove
+		}
+	}
+	return vote
+}
+
+// IsLGTM returns true if the comment approves the pull request
+func IsLGTM(body string) bool {
+	return ParseVote(body) == VoteApprove
+}
+
+// Vote is the current opinion of a user about a pull request
+type Vote struct {
+	Login string
+	// Approved is false when the user rejected the pull request or
+	// requested changes
+	Approved bool
+	// Stale is set on approvals given before the latest push
+	Stale bool
+	At    time.Time
+}
+
+type voteEvent struct {
+	login    string
+	vote     int
+	at       time.Time
+	commitID string
+}
+
+// CollectVotes returns the vote of each user who reviewed a pull request,
+// sorted by login. Reviews and comments are replayed in order, the latest
+// vote of a user being the one that counts.
+//
+// Approvals are stale when their review was left on another commit than
+// `head` or, for comments, when they were made before `pushedAt`.
+func CollectVotes(comments []gh.Comment, reviews []Review, head string, pushedAt time.Time) []Vote {
+	var events []voteEvent
+	for _, r := range reviews {
+		e := voteEvent{login: r.User.Login, at: r.SubmittedAt, commitID: r.CommitID}
+		switch r.State {
+		case ReviewApproved:
+			e.vote = VoteApprove
+		case ReviewChangesRequested:
+			e.vote = VoteReject
+		case ReviewDismissed:
+			e.vote = VoteWithdraw
+		default:
+			e.vote = ParseVote(r.Body)
+		}
+		events = append(events, e)
+	}
+	for _, c := range comments {
+		events = append(events, voteEvent{login: c.User.Login, vote: ParseVote(c.Body), at: c.CreatedAt})
+	}
+	sort.Stable(byEventTime(events))
+
+	votes := make(map[string]*Vote)
+	for _, e := range events {
+		switch e.vote {
+		case VoteApprove:
+			stale := e.at.Before(pushedAt)
+			if e.commitID != "" && head != "" {
+				stale = e.commitID != head
+			}
+			votes[e.login] = &Vote{Login: e.login, Approved: true, Stale: stale, At: e.at}
+		case VoteReject:
+			votes[e.login] = &Vote{Login: e.login, At: e.at}
+		case VoteWithdraw:
+			delete(votes, e.login)
+		}
+	}
+
+	out := make([]Vote, 0, len(votes))
+	for _, v := range votes {
+		out = append(out, *v)
+	}
+	sort.Sort(byVoteLogin(out))
+	return out
+}
+
+// GetApprovalForPR loads the MAINTAINERS files of the current repository and
+// checks which of the subsystems touched by `patch` were approved by `votes`
+func GetApprovalForPR(patch []byte, votes []Vote) (*Approval, error) {
+	subsystems, err := GetSubsystemsForPR(patch)
+	if err != nil {
+		return nil, err
+	}
+	return ApproveSubsystems(subsystems, votes), nil
+}
+
+// GetSubsystemsForPR loads the MAINTAINERS files of the current repository and
+// returns the subsystems touched by `patch`
+func GetSubsystemsForPR(patch []byte) ([]*Subsystem, error) {
+	toplevel, err := GetTopLevelGitRepo()
+	if err != nil {
+		return nil, err
+	}
+	maintainers, err := GetMaintainersFromRepo(toplevel, true)
+	if err != nil {
+		return nil, err
+	}
+	return PatchSubsystems(patch, maintainers)
+}
+
+// PatchSubsystems groups the files affected by a git-formatted patch by the
+// MAINTAINERS entry that owns them. The result is sorted by path.
+func PatchSubsystems(input []byte, maintainers map[string][]string) ([]*Subsystem, error) {
+	var (
+		index      = buildFileIndex(maintainers)
+		subsystems = make(map[string]*Subsystem)
+		seen       = make(map[string]bool)
+	)
+
+	set, err := patch.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range set.File {
+		for _, originalTarget := range []string{f.Dst, f.Src} {
+			if originalTarget == "" || seen[originalTarget] {
+				continue
+			}
+			seen[originalTarget] = true
+
+			owner, fileMaintainers := findOwner(index, path.Clean(originalTarget))
+			s, exists := subsystems[owner]
+			if !exists {
+				s = &Subsystem{Path: owner, Maintainers: maintainerLogins(fileMaintainers)}
+				subsystems[owner] = s
+			}
+			s.Files = append(s.Files, originalTarget)
+		}
+	}
+
+	out := make([]*Subsystem, 0, len(subsystems))
+	for _, s := range subsystems {
+		out = append(out, s)
+	}
+	sort.Sort(bySubsystemPath(out))
+	return out, nil
+}
+
+// ApproveSubsystems fills the approvers of each subsystem with the maintainers
+// who approved the pull request. Subsystems without maintainers accept an
+// LGTM from anybody so that repositories without MAINTAINERS files keep working.
+// Only the rejections of the maintainers of the subsystems block it.
+func ApproveSubsystems(subsystems []*Subsystem, votes []Vote) *Approval {
+	var (
+		approval    = &Approval{Subsystems: subsystems}
+		lgtms       = make(map[string]bool)
+		maintainers = make(map[string]bool)
+	)
+	for _, s := range subsystems {
+		for _, login := range s.Maintainers {
+			maintainers[login] = true
+		}
+	}
+	for _, v := range votes {
+		switch {
+		case v.Approved && v.Stale:
+			approval.Stale = append(approval.Stale, v.Login)
+		case v.Approved:
+			lgtms[v.Login] = true
+			approval.LGTMs = append(approval.LGTMs, v.Login)
+		case maintainers[v.Login]:
+			approval.Blockers = append(approval.Blockers, v.Login)
+		default:
+			approval.Rejections = append(approval.Rejections, v.Login)
+		}
+	}
+
+	for _, s := range subsystems {
+		s.Approvers = nil
+		if len(s.Maintainers) == 0 {
+			s.Approvers = append(s.Approvers, approval.LGTMs...)
+			continue
+		}
+		for _, login := range s.Maintainers {
+			if lgtms[login] {
+				s.Approvers = append(s.Approvers, login)
+			}
+		}
+	}
+	return approval
+}
+
+// findOwner moves up from `target` until it finds a path with maintainers in the index
+func findOwner(index map[string]map[string]bool, target string) (string, map[string]bool) {
+	for {
+		if fileMaintainers := index[target]; len(fileMaintainers) > 0 {
+			return target, fileMaintainers
+		}
+		parent := path.Dir(target)
+		if parent == target {
+			return target, nil
+		}
+		target = parent
+	}
+}
+
+// maintainerLogins returns the sorted github usernames of the maintainers, or
+// their emails when none of them have a username
+func maintainerLogins(maintainers map[string]bool) []string {
+	var logins, emails []string
+	for m := range maintainers {
+		if strings.Contains(m, "@") {
+			emails = append(emails, m)
+		} else if m != "" {
+			logins = append(logins, m)
+		}
+	}
+	if len(logins) == 0 {
+		logins = emails
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+type bySubsystemPath []*Subsystem
+
+func (a bySubsystemPath) Len() int           { return len(a) }
+func (a bySubsystemPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a bySubsystemPath) Less(i, j int) bool { return a[i].Path < a[j].Path }
+
+type byVoteLogin []Vote
+
+func (a byVoteLogin) Len() int           { return len(a) }
+func (a byVoteLogin) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byVoteLogin) Less(i, j int) bool { return a[i].Login < a[j].Login }
+
+type byEventTime []voteEvent
+
+func (a byEventTime) Len() int           { return len(a) }
+func (a byEventTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byEventTime) Less(i, j int) bool { return a[i].at.Before(a[j].at) }
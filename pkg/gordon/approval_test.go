@@ -0,0 +1,239 @@
+package gordon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+const testPatch = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+diff --git a/daemon/daemon.go b/daemon/daemon.go
+--- a/daemon/daemon.go
++++ b/daemon/daemon.go
+@@ -1 +1 @@
+-old
++new
+`
+
+func TestPatchSubsystems(t *testing.T) {
+	for _, test := range []struct {
+		maintainers map[string][]string
+		expected    string
+	}{
+		{map[string][]string{"alice": {"."}, "bob": {"daemon"}}, "[.:[README.md]:[alice] daemon:[daemon/daemon.go]:[bob]]"},
+		{map[string][]string{"alice": {"."}, "bob": {"."}}, "[.:[README.md daemon/daemon.go]:[alice bob]]"},
+		// without maintainers, everything is in one subsystem
+		{map[string][]string{}, "[.:[README.md daemon/daemon.go]:[]]"},
+	} {
+		subsystems, err := PatchSubsystems([]byte(testPatch), test.maintainers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, s := range subsystems {
+			out = append(out, fmt.Sprintf("%s:%v:%v", s.Path, s.Files, s.Maintainers))
+		}
+		if fmt.Sprint(out) != test.expected {
+			t.Errorf("%v: expected %s, got %v", test.maintainers, test.expected, out)
+		}
+	}
+}
+
+func TestApproveSubsystems(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		votes     []Vote
+		approvers string
+		approved  bool
+	}{
+		{"no votes", nil, "[[] [] []]", false},
+		{"one maintainer", []Vote{{Login: "alice", Approved: true}}, "[[alice] [] [alice]]", false},
+		{"every subsystem", []Vote{{Login: "alice", Approved: true}, {Login: "bob", Approved: true}}, "[[alice] [bob] [alice bob]]", true},
+		// anybody approves the subsystems without maintainers
+		{"not a maintainer", []Vote{{Login: "carol", Approved: true}}, "[[] [] [carol]]", false},
+		{"stale", []Vote{{Login: "alice", Approved: true, Stale: true}, {Login: "bob", Approved: true}}, "[[] [bob] [bob]]", false},
+		{"rejected", []Vote{{Login: "alice"}, {Login: "bob", Approved: true}}, "[[] [bob] [bob]]", false},
+	} {
+		subsystems := []*Subsystem{
+			{Path: ".", Maintainers: []string{"alice"}},
+			{Path: "daemon", Maintainers: []string{"bob"}},
+			{Path: "docs"},
+		}
+		approval := ApproveSubsystems(subsystems, test.votes)
+		var approvers [][]string
+		for _, s := range approval.Subsystems {
+			approvers = append(approvers, s.Approvers)
+		}
+		if fmt.Sprint(approvers) != test.approvers || approval.Approved() != test.approved {
+			t.Errorf("%s: expected %s approved %v, got %v approved %v", test.name, test.approvers, test.approved, approvers, approval.Approved())
+		}
+	}
+
+	// a pull request changing nothing isn't approved
+	if ApproveSubsystems(nil, []Vote{{Login: "alice", Approved: true}}).Approved() {
+		t.Errorf("expected no subsystem not to be approved")
+	}
+}
+
+func TestBlockers(t *testing.T) {
+	subsystems := func() []*Subsystem {
+		return []*Subsystem{
+			{Path: ".", Files: []string{"README.md"}, Maintainers: []string{"alice"}},
+			{Path: "daemon", Files: []string{"daemon/daemon.go"}, Maintainers: []string{"bob"}},
+		}
+	}
+	votes := []Vote{
+		{Login: "bob"},
+		{Login: "carol", Approved: true},
+		{Login: "lead"},
+		{Login: "mallory"},
+	}
+
+	approval := ApproveSubsystems(subsystems(), votes)
+	if fmt.Sprint(approval.Blockers) != "[bob]" || fmt.Sprint(approval.Rejections) != "[lead mallory]" {
+		t.Errorf("expected bob to block, got blockers %v and rejections %v", approval.Blockers, approval.Rejections)
+	}
+
+	result := DefaultPolicy.Evaluate(testPR("dave"), subsystems(), votes, testStatus(""), []string{"lead"})
+	if fmt.Sprint(result.Approval.Blockers) != "[bob lead]" || fmt.Sprint(result.Approval.Rejections) != "[mallory]" {
+		t.Errorf("expected bob and the lead to block, got blockers %v and rejections %v", result.Approval.Blockers, result.Approval.Rejections)
+	}
+
+	// a drive-by rejection doesn't block the merge
+	votes = []Vote{{Login: "alice", Approved: true}, {Login: "bob", Approved: true}, {Login: "mallory"}}
+	result = DefaultPolicy.Evaluate(testPR("dave"), subsystems(), votes, testStatus(""), []string{"lead"})
+	if !result.Passed() {
+		t.Errorf("expected the rejection of mallory to be ignored, got %s", result.Error())
+	}
+}
+
+func TestParseVote(t *testing.T) {
+	for _, test := range []struct {
+		body     string
+		expected int
+	}{
+		{"LGTM", VoteApprove},
+		{"LGTM, thanks!", VoteApprove},
+		{"Looks good.\nLGTM", VoteApprove},
+		{"lgtm", NoVote},
+		{"LGTMs are counted", NoVote},
+		{"needs a rebase", NoVote},
+		{"NOT LGTM", VoteReject},
+		{"not lgtm, this breaks the api", VoteReject},
+		{"Not\tLGTM", VoteReject},
+		{"LGTM\nactually, NOT LGTM", VoteReject},
+		{"LGTM withdrawn", VoteWithdraw},
+		{"I withdraw my LGTM", VoteWithdraw},
+		{"revoking my LGTM until the tests pass", VoteWithdraw},
+		// quoted lines are someone else's
+		{"> LGTM\nwhy?", NoVote},
+		{"> NOT LGTM\nLGTM", VoteApprove},
+		{"  > LGTM withdrawn", NoVote},
+	} {
+		if vote := ParseVote(test.body); vote != test.expected {
+			t.Errorf("%q: expected %d, got %d", test.body, test.expected, vote)
+		}
+	}
+}
+
+func TestCollectVotesStale(t *testing.T) {
+	var (
+		head     = "abcdef"
+		pushedAt = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	)
+	comment := func(login, body string, at time.Time) gh.Comment {
+		return gh.Comment{User: gh.User{Login: login}, Body: body, CreatedAt: at}
+	}
+	review := func(login, commitID string, at time.Time) Review {
+		return Review{User: gh.User{Login: login}, State: ReviewApproved, CommitID: commitID, SubmittedAt: at}
+	}
+	for _, test := range []struct {
+		name     string
+		comments []gh.Comment
+		reviews  []Review
+		expected string
+	}{
+		// comments are stale when made before the latest push
+		{"comment before the push", []gh.Comment{comment("alice", "LGTM", pushedAt.Add(-time.Minute))}, nil, "alice:stale"},
+		{"comment after the push", []gh.Comment{comment("alice", "LGTM", pushedAt.Add(time.Minute))}, nil, "alice:approved"},
+		{"approved again", []gh.Comment{comment("alice", "LGTM", pushedAt.Add(-time.Hour)), comment("alice", "LGTM", pushedAt.Add(time.Hour))}, nil, "alice:approved"},
+		// reviews are stale when left on another commit, whatever the date
+		{"review of an older commit", nil, []Review{review("alice", "012345", pushedAt.Add(time.Hour))}, "alice:stale"},
+		{"review of the head", nil, []Review{review("alice", head, pushedAt.Add(-time.Hour))}, "alice:approved"},
+		{"review without a commit", nil, []Review{review("alice", "", pushedAt.Add(-time.Hour))}, "alice:stale"},
+		// rejections never go stale
+		{"rejection before the push", []gh.Comment{comment("alice", "NOT LGTM", pushedAt.Add(-time.Hour))}, nil, "alice:rejected"},
+	} {
+		if v := votes(CollectVotes(test.comments, test.reviews, head, pushedAt)); v != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, v)
+		}
+	}
+}
+
+// votes describes the votes as login:approved, login:stale or login:rejected
+func votes(v []Vote) string {
+	var out []string
+	for _, vote := range v {
+		switch {
+		case vote.Approved && vote.Stale:
+			out = append(out, vote.Login+":stale")
+		case vote.Approved:
+			out = append(out, vote.Login+":approved")
+		default:
+			out = append(out, vote.Login+":rejected")
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestCollectVotesReviews(t *testing.T) {
+	var (
+		head = "abcdef"
+		t0   = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+		at   = func(minutes int) time.Time { return t0.Add(time.Duration(minutes) * time.Minute) }
+	)
+	comment := func(login, body string, minutes int) gh.Comment {
+		return gh.Comment{User: gh.User{Login: login}, Body: body, CreatedAt: at(minutes)}
+	}
+	review := func(login, state, body string, minutes int) Review {
+		return Review{User: gh.User{Login: login}, State: state, Body: body, CommitID: head, SubmittedAt: at(minutes)}
+	}
+	for _, test := range []struct {
+		name     string
+		comments []gh.Comment
+		reviews  []Review
+		expected string
+	}{
+		{"approved review", nil, []Review{review("alice", ReviewApproved, "", 1)}, "alice:approved"},
+		{"changes requested", nil, []Review{review("alice", ReviewChangesRequested, "", 1)}, "alice:rejected"},
+		{"commented review", nil, []Review{review("alice", ReviewCommented, "LGTM", 1), review("bob", ReviewCommented, "nice", 1)}, "alice:approved"},
+		// the latest vote counts, whether it's a review or a comment
+		{"comment after review", []gh.Comment{comment("alice", "NOT LGTM", 2)}, []Review{review("alice", ReviewApproved, "", 1)}, "alice:rejected"},
+		{"review after comment", []gh.Comment{comment("alice", "NOT LGTM", 1)}, []Review{review("alice", ReviewApproved, "", 2)}, "alice:approved"},
+		{"review after changes", nil, []Review{review("alice", ReviewApproved, "", 3), review("alice", ReviewChangesRequested, "", 2)}, "alice:approved"},
+		// a dismissed review takes the vote back
+		{"dismissed", nil, []Review{review("alice", ReviewApproved, "", 1), review("alice", ReviewDismissed, "", 2)}, ""},
+		{"dismissed then comment", []gh.Comment{comment("alice", "LGTM", 3)}, []Review{review("alice", ReviewChangesRequested, "", 1), review("alice", ReviewDismissed, "", 2)}, "alice:approved"},
+		{"sorted by login", []gh.Comment{comment("carol", "LGTM", 1)}, []Review{review("bob", ReviewChangesRequested, "", 2), review("alice", ReviewApproved, "", 3)}, "alice:approved bob:rejected carol:approved"},
+	} {
+		if v := votes(CollectVotes(test.comments, test.reviews, head, time.Time{})); v != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, v)
+		}
+	}
+}
+
+func testPR(author string) *gh.PullRequest {
+	return &gh.PullRequest{Number: 1, User: gh.User{Login: author}}
+}
+
+func testStatus(state string) gh.CombinedStatus {
+	return gh.CombinedStatus{State: state}
+}
@@ -13,37 +13,39 @@ func init() {
 	}
 }
 
-func Green(s string) string {
-	if Colorize {
-		return brush.Green(s).String()
+// brushes color a string, by name
+var brushes = map[string]func(string) string{
+	"green":      func(s string) string { return brush.Green(s).String() },
+	"red":        func(s string) string { return brush.Red(s).String() },
+	"darkred":    func(s string) string { return brush.DarkRed(s).String() },
+	"yellow":     func(s string) string { return brush.Yellow(s).String() },
+	"darkyellow": func(s string) string { return brush.DarkYellow(s).String() },
+}
+
+// paint colors `s` with the brush named `color` when `on`
+func paint(on bool, color, s string) string {
+	if on {
+		return brushes[color](s)
 	}
 	return s
 }
 
+func Green(s string) string {
+	return paint(Colorize, "green", s)
+}
+
 func Red(s string) string {
-	if Colorize {
-		return brush.Red(s).String()
-	}
-	return s
+	return paint(Colorize, "red", s)
 }
 
 func DarkRed(s string) string {
-	if Colorize {
-		return brush.DarkRed(s).String()
-	}
-	return s
+	return paint(Colorize, "darkred", s)
 }
 
 func DarkYellow(s string) string {
-	if Colorize {
-		return brush.DarkYellow(s).String()
-	}
-	return s
+	return paint(Colorize, "darkyellow", s)
 }
 
 func Yellow(s string) string {
-	if Colorize {
-		return brush.Yellow(s).String()
-	}
-	return s
+	return paint(Colorize, "yellow", s)
 }
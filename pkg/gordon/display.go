@@ -2,17 +2,15 @@ package gordon
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
-	"github.com/urfave/cli"
 	gh "github.com/crosbymichael/octokat"
 )
 
@@ -21,8 +19,105 @@ const (
 	truncSize         = 80
 )
 
-func newTabwriter() *tabwriter.Writer {
-	return tabwriter.NewWriter(os.Stdout, 8, 1, 3, ' ', 0)
+// RenderOptions select what a Renderer shows and how, they are the display
+// flags of the commands
+type RenderOptions struct {
+	// Format is one of Formats, or FormatTemplate to execute Template for
+	// each item
+	Format   string
+	Template *template.Template
+	// Color colors the tables
+	Color bool
+	// NoTrunc doesn't truncate the titles
+	NoTrunc bool
+	// LGTM adds the approval column to the pull requests
+	LGTM bool
+	// Votes adds the votes column to the issues
+	Votes bool
+	// CIContext is the status context the CI column shows, the combined
+	// status when empty
+	CIContext string
+	// Sizes are the buckets of the size column
+	Sizes Sizes
+	// Top is the number of contributors shown, all of them when 0
+	Top int
+	// ContributorsBy sorts the contributors by "additions", "deletions" or
+	// "commits", the default
+	ContributorsBy string
+}
+
+// DefaultRenderOptions returns the options of the format selected by
+// SetFormat, colored when the standard output is a terminal
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		Format:   Format,
+		Template: formatTemplate,
+		Color:    Colorize,
+		Sizes:    DefaultSizes,
+	}
+}
+
+// Renderer writes pull requests, issues and the rest to a writer, as tables
+// or in the machine formats
+type Renderer struct {
+	RenderOptions
+	w *errWriter
+}
+
+// NewRenderer returns a Renderer writing to `w`
+func NewRenderer(w io.Writer, o RenderOptions) *Renderer {
+	if o.Format == "" {
+		o.Format = FormatTable
+	}
+	return &Renderer{RenderOptions: o, w: &errWriter{w: w}}
+}
+
+// errWriter stops writing after the first error, returned by the renderer
+// once done
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
+
+func (r *Renderer) err() error {
+	return r.w.err
+}
+
+func (r *Renderer) machine() bool {
+	return r.Format != FormatTable
+}
+
+func (r *Renderer) tabwriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(r.w, 8, 1, 3, ' ', 0)
+}
+
+func (r *Renderer) green(s string) string {
+	return paint(r.Color, "green", s)
+}
+
+func (r *Renderer) red(s string) string {
+	return paint(r.Color, "red", s)
+}
+
+func (r *Renderer) darkRed(s string) string {
+	return paint(r.Color, "darkred", s)
+}
+
+func (r *Renderer) yellow(s string) string {
+	return paint(r.Color, "yellow", s)
+}
+
+func (r *Renderer) darkYellow(s string) string {
+	return paint(r.Color, "darkyellow", s)
 }
 
 func truncate(s string) string {
@@ -32,62 +127,205 @@ func truncate(s string) string {
 	return s
 }
 
-func DisplayPullRequests(c *cli.Context, pulls []*gh.PullRequest, notrunc bool) {
-	w := newTabwriter()
-	fmt.Fprintf(w, "NUMBER\tSHA\tLAST UPDATED\tCONTRIBUTOR\tASSIGNEE\tTITLE")
-	if c.Bool("lgtm") {
+// PullRequests lists the pull requests
+func (r *Renderer) PullRequests(pulls []*PullRequest) error {
+	if r.machine() {
+		records := make([]Record, len(pulls))
+		for i, p := range pulls {
+			records[i] = pullRequestRecord(p, r.CIContext, r.Sizes)
+		}
+		return r.records(pulls, records, false)
+	}
+
+	// the size and ci columns are only shown when they were fetched for
+	// some of the pull requests
+	var sizes, statuses bool
+	for _, p := range pulls {
+		_, known := p.Lines()
+		sizes = sizes || known
+		statuses = statuses || p.Status != nil
+	}
+
+	w := r.tabwriter()
+	fmt.Fprintf(w, "NUMBER\tSHA\tLAST UPDATED\tCONTRIBUTOR\tASSIGNEE\tTITLE\tLABELS")
+	if sizes {
+		fmt.Fprintf(w, "\tSIZE")
+	}
+	if statuses {
+		fmt.Fprintf(w, "\tCI")
+	}
+	if r.LGTM {
 		fmt.Fprintf(w, "\tLGTM")
 	}
 	fmt.Fprintf(w, "\n")
 	for _, p := range pulls {
-		if !notrunc {
-			p.Title = truncate(p.Title)
+		title := p.Title
+		if !r.NoTrunc {
+			title = truncate(title)
 		}
 		var assignee string
 		if p.Assignee != nil {
 			assignee = p.Assignee.Login
 		}
-		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s", p.Number, p.Head.Sha[:8], HumanDuration(time.Since(p.UpdatedAt)), p.User.Login, assignee, p.Title)
-		if c.Bool("lgtm") {
-			lgtm := strconv.Itoa(p.ReviewComments)
-			if p.ReviewComments >= 2 {
-				lgtm = Green(lgtm)
-			} else if p.ReviewComments == 0 {
-				lgtm = DarkRed(lgtm)
-			} else {
-				lgtm = DarkYellow(lgtm)
-			}
-			fmt.Fprintf(w, "\t%s", lgtm)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s", p.Number, p.Head.Sha[:8], HumanDuration(time.Since(p.UpdatedAt)), p.User.Login, assignee, title, labelsColumn(p.Labels))
+		if sizes {
+			fmt.Fprintf(w, "\t%s", r.sizeColumn(p))
+		}
+		if statuses {
+			fmt.Fprintf(w, "\t%s", r.ciColumn(p.CIState(r.CIContext)))
+		}
+		if r.LGTM {
+			fmt.Fprintf(w, "\t%s", r.lgtmColumn(p.Approval))
 		}
 		fmt.Fprintf(w, "\n")
 	}
 
-	if err := w.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+	w.Flush()
+	return r.err()
+}
+
+// labelsColumn lists the names of the labels, separated by commas
+func labelsColumn(labels []gh.Label) string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// sizeColumn shows the size of a pull request, the largest ones in red
+func (r *Renderer) sizeColumn(p *PullRequest) string {
+	lines, known := p.Lines()
+	if !known {
+		return "-"
+	}
+	switch label := r.Sizes.Label(lines); label {
+	case "XS", "S":
+		return r.green(label)
+	case "XL":
+		return r.red(label)
+	default:
+		return label
 	}
 }
 
-func DisplayReviewers(c *cli.Context, reviewers map[string][]string) {
-	w := newTabwriter()
+// ciColumn colors the state of the status of a pull request
+func (r *Renderer) ciColumn(state string) string {
+	switch state {
+	case CISuccess:
+		return r.green(state)
+	case CIFailure, CIError:
+		return r.red(state)
+	case CIPending:
+		return r.yellow(state)
+	}
+	return "-"
+}
+
+// lgtmColumn shows the number of LGTMs from maintainers followed by the
+// number of stale and rejecting votes
+func (r *Renderer) lgtmColumn(approval *Approval) string {
+	if approval == nil {
+		return ""
+	}
+	count := len(approval.Approvers())
+	lgtm := strconv.Itoa(count)
+	if count >= 2 {
+		lgtm = r.green(lgtm)
+	} else if count == 0 {
+		lgtm = r.darkRed(lgtm)
+	} else {
+		lgtm = r.darkYellow(lgtm)
+	}
+	if n := len(approval.Stale); n > 0 {
+		lgtm += " " + r.darkYellow(fmt.Sprintf("(%d stale)", n))
+	}
+	if n := len(approval.Blockers); n > 0 {
+		lgtm += " " + r.red(fmt.Sprintf("(%d against)", n))
+	}
+	return lgtm
+}
+
+// Reviewers lists the reviewers of each file
+func (r *Renderer) Reviewers(reviewers map[string][]string) error {
+	if r.machine() {
+		return r.records(nil, reviewerRecords(reviewers), false)
+	}
+	w := r.tabwriter()
 	fmt.Fprintf(w, "FILE\tREVIEWERS")
 	fmt.Fprintf(w, "\n")
-	for file, fileReviewers := range reviewers {
-		var usernames bytes.Buffer
-		for _, reviewer := range fileReviewers {
-			usernames.WriteString(reviewer)
-			usernames.WriteString(", ")
+	files := make([]string, 0, len(reviewers))
+	for file := range reviewers {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		fileReviewers := append([]string{}, reviewers[file]...)
+		sort.Strings(fileReviewers)
+		fmt.Fprintf(w, "%s\t%s\n", file, strings.Join(fileReviewers, ", "))
+	}
+	w.Flush()
+	return r.err()
+}
+
+// OwnershipTree prints the directory tree with the maintainers of each
+// path, inherited maintainers are printed in yellow
+func (r *Renderer) OwnershipTree(tree *OwnershipTree) error {
+	if r.machine() {
+		return r.records(nil, ownershipRecords(tree, nil), false)
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "PATH\tMAINTAINERS\n")
+	r.ownershipNode(w, tree, "", "")
+	w.Flush()
+	return r.err()
+}
+
+func (r *Renderer) ownershipNode(w io.Writer, node *OwnershipTree, prefix, childPrefix string) {
+	owners := mentions(node.Maintainers)
+	if node.Inherited {
+		owners = r.darkYellow(owners + " (inherited)")
+	} else if len(node.Maintainers) == 0 {
+		owners = r.red("none")
+	}
+	fmt.Fprintf(w, "%s%s\t%s\n", prefix, node.Name, owners)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			r.ownershipNode(w, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			r.ownershipNode(w, child, childPrefix+"├── ", childPrefix+"│   ")
 		}
-		usernames.Truncate(usernames.Len() - 2)
-		fmt.Fprintf(w, "%s\t%s\n", file, usernames.String())
 	}
-	if err := w.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
+}
+
+// Maintainer prints the paths owned by a maintainer and the pull requests
+// touching them
+func (r *Renderer) Maintainer(maintainer string, paths []string, pulls []*PullRequest) error {
+	if r.machine() {
+		return r.records(nil, []Record{maintainerRecord(maintainer, paths, pulls)}, true)
+	}
+	fmt.Fprintf(r.w, "Maintainer: %s\n\nPaths:\n", r.green(maintainer))
+	for _, p := range paths {
+		fmt.Fprintf(r.w, "\t%s\n", p)
+	}
+	fmt.Fprintf(r.w, "\nOpen pull requests touching these paths: %d\n\n", len(pulls))
+	if len(pulls) == 0 {
+		return r.err()
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "NUMBER\tLAST UPDATED\tCONTRIBUTOR\tFILES\tTITLE\n")
+	for _, p := range pulls {
+		files := maintainedFiles(p, maintainer)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", p.Number, HumanDuration(time.Since(p.UpdatedAt)), p.User.Login, truncate(strings.Join(files, ", ")), truncate(p.Title))
 	}
+	w.Flush()
+	return r.err()
 }
 
-func DisplayContributors(c *cli.Context, contributors []*gh.Contributor) {
+// Contributors lists the additions, deletions and commits of the contributors
+func (r *Renderer) Contributors(contributors []*gh.Contributor) error {
 	var (
-		w                 = newTabwriter()
+		w                 = r.tabwriter()
 		contributorsStats []ContributorStats
 	)
 
@@ -101,20 +339,33 @@ func DisplayContributors(c *cli.Context, contributors []*gh.Contributor) {
 		}
 		contributorsStats = append(contributorsStats, []ContributorStats{contribStats}...)
 	}
-	if c.Bool("additions") {
+	switch r.ContributorsBy {
+	case "additions":
 		sort.Sort(ByAdditions(contributorsStats))
-	} else if c.Bool("deletions") {
+	case "deletions":
 		sort.Sort(ByDeletions(contributorsStats))
-	} else if c.Bool("commits") {
-		sort.Sort(ByCommits(contributorsStats))
-	} else {
+	default:
 		// Sort by default by Commits
 		sort.Sort(ByCommits(contributorsStats))
 	}
-	topN := c.Int("top")
+	if r.Top > 0 && len(contributorsStats) > r.Top {
+		contributorsStats = contributorsStats[:r.Top]
+	}
+	if r.machine() {
+		records := make([]Record, len(contributorsStats))
+		for i, s := range contributorsStats {
+			records[i] = Record{
+				{"login", s.Name},
+				{"additions", s.Additions},
+				{"deletions", s.Deletions},
+				{"commits", s.Commits},
+			}
+		}
+		return r.records(contributorsStats, records, false)
+	}
 	fmt.Fprintf(w, "CONTRIBUTOR\tADDITIONS\tDELETIONS\tCOMMITS")
 	fmt.Fprintf(w, "\n")
-	for i := 0; i < len(contributorsStats) && i < topN; i++ {
+	for i := 0; i < len(contributorsStats); i++ {
 		fmt.Fprintf(w, "%s\t%d\t%d\t%d", contributorsStats[i].Name,
 			contributorsStats[i].Additions,
 			contributorsStats[i].Deletions,
@@ -122,47 +373,60 @@ func DisplayContributors(c *cli.Context, contributors []*gh.Contributor) {
 		fmt.Fprintf(w, "\n")
 	}
 
-	if err := w.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
-	}
+	w.Flush()
+	return r.err()
 }
 
-func DisplayPullRequest(pr *gh.PullRequest, status gh.CombinedStatus) {
-	fmt.Fprint(os.Stdout, fmt.Sprintf("Pull Request from: %s", Green("@"+pr.User.Login)), "\n")
-	fmt.Printf("No: %d\nSha: %s\nTitle: %s\n", pr.Number, pr.Head.Sha, pr.Title)
+// PullRequest shows a pull request with its status and approval
+func (r *Renderer) PullRequest(pr *PullRequest, status gh.CombinedStatus, approval *Approval) error {
+	if r.machine() {
+		pr.Status, pr.Approval = &status, approval
+		record := append(pullRequestRecord(pr, "", r.Sizes),
+			Field{"body", pr.Body},
+			Field{"merged", pr.Merged},
+			Field{"statuses", statusRecords(status)},
+		)
+		return r.records(pr, []Record{record}, true)
+	}
+	fmt.Fprint(r.w, fmt.Sprintf("Pull Request from: %s", r.green("@"+pr.User.Login)), "\n")
+	fmt.Fprintf(r.w, "No: %d\nSha: %s\nTitle: %s\n", pr.Number, pr.Head.Sha, pr.Title)
 
 	if pr.Merged {
-		fmt.Fprintf(os.Stdout, "\nMerged by: %s\nMerged at: %s\nMerge Commit: %s\n\n", Yellow("@"+pr.MergedBy.Login), Yellow(pr.MergedAt.Format(time.RubyDate)), Yellow(pr.MergeCommitSha))
+		fmt.Fprintf(r.w, "\nMerged by: %s\nMerged at: %s\nMerge Commit: %s\n\n", r.yellow("@"+pr.MergedBy.Login), r.yellow(pr.MergedAt.Format(time.RubyDate)), r.yellow(pr.MergeCommitSha))
 	} else {
+		var state string
+		if pr.MergeableState != "" {
+			state = fmt.Sprintf(" (%s)", pr.MergeableState)
+		}
 		if pr.Mergeable != nil {
-			if pr.Mergeable != nil && *pr.Mergeable {
+			if *pr.Mergeable {
 				m := fmt.Sprintf("%t", *pr.Mergeable)
-				fmt.Fprintf(os.Stdout, "Mergeable: %s", Green(m))
+				fmt.Fprintf(r.w, "Mergeable: %s%s", r.green(m), state)
 			} else {
 				m := "false"
-				fmt.Fprintf(os.Stdout, "Mergeable: %s", Red(m))
+				fmt.Fprintf(r.w, "Mergeable: %s%s", r.red(m), state)
 			}
 		} else {
 			m := "unknown"
-			fmt.Fprintf(os.Stdout, "Mergeable: %s", Yellow(m))
+			fmt.Fprintf(r.w, "Mergeable: %s, GitHub is still computing it", r.yellow(m))
 		}
 	}
-	fmt.Fprint(os.Stdout, "\n")
+	fmt.Fprint(r.w, "\n")
 
 	var buildStatus string
 	switch status.State {
 	case "pending":
-		buildStatus = Yellow("none")
+		buildStatus = r.yellow("none")
 	case "success":
-		buildStatus = Green("success")
+		buildStatus = r.green("success")
 	case "error":
-		buildStatus = Red("error")
+		buildStatus = r.red("error")
 	case "failure":
-		buildStatus = Red("failure")
+		buildStatus = r.red("failure")
 	default:
-		buildStatus = Red("unknown")
+		buildStatus = r.red("unknown")
 	}
-	fmt.Fprintln(os.Stdout, "Build Status:", buildStatus)
+	fmt.Fprintln(r.w, "Build Status:", buildStatus)
 
 	states := make(map[string]gh.Status)
 	if status.State != "success" {
@@ -182,83 +446,220 @@ func DisplayPullRequest(pr *gh.PullRequest, status gh.CombinedStatus) {
 		statusString := fmt.Sprintln("\t" + v + ": " + states[v].State + " " + states[v].TargetURL)
 		switch states[v].State {
 		case "pending":
-			buildStatus = Yellow(statusString)
+			buildStatus = r.yellow(statusString)
 		case "success":
-			buildStatus = Green(statusString)
+			buildStatus = r.green(statusString)
 		default:
-			buildStatus = Red(statusString)
+			buildStatus = r.red(statusString)
 		}
-		fmt.Fprint(os.Stdout, buildStatus)
+		fmt.Fprint(r.w, buildStatus)
+	}
+
+	if approval != nil {
+		r.approval(approval)
 	}
 
 	lines := strings.Split(pr.Body, "\n")
 	for i, l := range lines {
 		lines[i] = "\t" + l
 	}
-	fmt.Printf("Description:\n\n%s\n\n", strings.Join(lines, "\n"))
-	fmt.Printf("\n\n")
+	fmt.Fprintf(r.w, "Description:\n\n%s\n\n", strings.Join(lines, "\n"))
+	fmt.Fprintf(r.w, "\n\n")
+	return r.err()
+}
+
+func (r *Renderer) approval(approval *Approval) {
+	if approval.Approved() {
+		fmt.Fprintln(r.w, "Approved:", r.green("yes"))
+	} else {
+		fmt.Fprintln(r.w, "Approved:", r.red("no"))
+	}
+	if len(approval.Blockers) > 0 {
+		fmt.Fprintln(r.w, "Changes requested by:", r.red(mentions(approval.Blockers)))
+	}
+	if len(approval.Rejections) > 0 {
+		fmt.Fprintln(r.w, "Also rejected by:", r.yellow(mentions(approval.Rejections)))
+	}
+	if len(approval.Stale) > 0 {
+		fmt.Fprintln(r.w, "Stale approvals from:", r.yellow(mentions(approval.Stale)))
+	}
+	for _, s := range approval.Subsystems {
+		if s.Approved() {
+			fmt.Fprintf(r.w, "\t%s: %s\n", s.Path, r.green("approved by "+mentions(s.Approvers)))
+		} else if len(s.Maintainers) == 0 {
+			fmt.Fprintf(r.w, "\t%s: %s\n", s.Path, r.yellow("needs an LGTM"))
+		} else {
+			fmt.Fprintf(r.w, "\t%s: %s\n", s.Path, r.red("needs one of "+mentions(s.Maintainers)))
+		}
+	}
+}
+
+// Policy shows the rules of the merge policy a pull request meets
+func (r *Renderer) Policy(pr *gh.PullRequest, result *PolicyResult) error {
+	if r.machine() {
+		rules := []Record{}
+		for _, rule := range result.Rules {
+			rules = append(rules, Record{
+				{"name", rule.Name},
+				{"passed", rule.Passed},
+				{"reason", rule.Reason},
+			})
+		}
+		return r.records(nil, []Record{{
+			{"number", pr.Number},
+			{"passed", result.Passed()},
+			{"rules", rules},
+		}}, true)
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "RULE\tSTATE\tDETAILS\n")
+	for _, rule := range result.Rules {
+		state := r.green("ok")
+		if !rule.Passed {
+			state = r.red("unmet")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", rule.Name, state, rule.Reason)
+	}
+	w.Flush()
+	if result.Passed() {
+		fmt.Fprintf(r.w, "\nPull request %d can be merged\n", pr.Number)
+	} else {
+		fmt.Fprintf(r.w, "\nPull request %d can't be merged without --force\n", pr.Number)
+	}
+	return r.err()
+}
+
+// Workloads prints what's waiting on each maintainer
+func (r *Renderer) Workloads(workloads []*Workload) error {
+	if r.machine() {
+		records := make([]Record, len(workloads))
+		for i, wl := range workloads {
+			var oldest, oldestAt interface{}
+			if wl.OldestPing != nil {
+				oldest, oldestAt = wl.OldestPing.Number, timeValue(wl.OldestPingAt)
+			}
+			records[i] = Record{
+				{"maintainer", wl.Maintainer},
+				{"open", len(wl.Touching)},
+				{"assigned", len(wl.Assigned)},
+				{"pinged", len(wl.Pinged)},
+				{"oldest_ping", oldest},
+				{"oldest_ping_at", oldestAt},
+			}
+		}
+		return r.records(workloads, records, false)
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "MAINTAINER\tOPEN\tASSIGNED\tPINGED\tOLDEST PING\n")
+	for _, wl := range workloads {
+		var oldest string
+		if wl.OldestPing != nil {
+			oldest = fmt.Sprintf("#%d (%s)", wl.OldestPing.Number, HumanDuration(time.Since(wl.OldestPingAt)))
+		}
+		pinged := strconv.Itoa(len(wl.Pinged))
+		if len(wl.Pinged) > 0 {
+			pinged = r.red(pinged)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", wl.Maintainer, len(wl.Touching), len(wl.Assigned), pinged, oldest)
+	}
+	w.Flush()
+	return r.err()
 }
 
-func DisplayComments(comments []gh.Comment) {
-	fmt.Fprintln(os.Stdout, "Comments:")
+func mentions(logins []string) string {
+	out := make([]string, len(logins))
+	for i, l := range logins {
+		if strings.Contains(l, "@") {
+			out[i] = l
+		} else {
+			out[i] = "@" + l
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// Comments shows the comments of an issue or a pull request
+func (r *Renderer) Comments(comments []gh.Comment) error {
+	if r.machine() {
+		return r.records(comments, commentRecords(comments), false)
+	}
+	fmt.Fprintln(r.w, "Comments:")
 	for _, c := range comments {
-		fmt.Printf("<%s\n@%s %s\n%s\n%s>", strings.Repeat("=", 79), Red(c.User.Login), c.CreatedAt.Format(defaultTimeFormat), strings.Replace(c.Body, "LGTM", fmt.Sprintf("%s", Green("LGTM")), -1), strings.Repeat("=", 79))
-		fmt.Fprint(os.Stdout, "\n\n")
+		fmt.Fprintf(r.w, "<%s\n@%s %s\n%s\n%s>", strings.Repeat("=", 79), r.red(c.User.Login), c.CreatedAt.Format(defaultTimeFormat), strings.Replace(c.Body, "LGTM", fmt.Sprintf("%s", r.green("LGTM")), -1), strings.Repeat("=", 79))
+		fmt.Fprint(r.w, "\n\n")
 	}
+	return r.err()
 }
 
-func DisplayCommentAdded(cmt gh.Comment) {
-	fmt.Printf("Comment added at %s\n", cmt.CreatedAt.Format(defaultTimeFormat))
+// CommentAdded confirms a comment was added
+func (r *Renderer) CommentAdded(cmt gh.Comment) error {
+	fmt.Fprintf(r.w, "Comment added at %s\n", cmt.CreatedAt.Format(defaultTimeFormat))
+	return r.err()
 }
 
-func printIssue(c *cli.Context, w *tabwriter.Writer, number int, updatedAt time.Time, login, milestone, title string, comments int) {
+func (r *Renderer) printIssue(w *tabwriter.Writer, number int, updatedAt time.Time, login, milestone, title string, votes int) {
 	fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s", number, HumanDuration(time.Since(updatedAt)), login, milestone, title)
-	if c.Int("votes") > 0 {
-		votes := strconv.Itoa(comments)
-		if comments >= 2 {
-			votes = Green(votes)
+	if r.Votes {
+		column := strconv.Itoa(votes)
+		if votes >= 2 {
+			column = r.green(column)
 		}
-		fmt.Fprintf(w, "\t%s", votes)
+		fmt.Fprintf(w, "\t%s", column)
 	}
 	fmt.Fprintf(w, "\n")
 }
 
-// Display Issues prints `issues` to standard output in a human-friendly tabulated format.
-func DisplayIssues(c *cli.Context, v interface{}, notrunc bool) {
-	w := newTabwriter()
-	fmt.Fprintf(w, "NUMBER\tLAST UPDATED\tASSIGNEE\tMILESTONE\nTITLE")
-	if c.Int("votes") > 0 {
+// Issues lists `issues`, a slice of *Issue, *gh.Issue or *gh.SearchItem, in a
+// human-friendly tabulated format
+func (r *Renderer) Issues(v interface{}) error {
+	if r.machine() {
+		return r.records(v, issueRecords(v, r.Votes), false)
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "NUMBER\tLAST UPDATED\tASSIGNEE\tMILESTONE\tTITLE")
+	if r.Votes {
 		fmt.Fprintf(w, "\tVOTES")
 	}
 	fmt.Fprintf(w, "\n")
 
 	switch issues := v.(type) {
+	case []*Issue:
+		for _, p := range issues {
+			r.printIssue(w, p.Number, p.UpdatedAt, p.Assignee.Login, p.Milestone.Title, p.Title, p.Votes)
+		}
 	case []*gh.Issue:
 		for _, p := range issues {
-			printIssue(c, w, p.Number, p.UpdatedAt, p.Assignee.Login, p.Milestone.Title, p.Title, p.Comments)
+			r.printIssue(w, p.Number, p.UpdatedAt, p.Assignee.Login, p.Milestone.Title, p.Title, p.Comments)
 		}
 	case []*gh.SearchItem:
 		for _, p := range issues {
-			printIssue(c, w, p.Number, p.UpdatedAt, p.Assignee.Login, p.Milestone.Title, p.Title, p.Comments)
+			r.printIssue(w, p.Number, p.UpdatedAt, p.Assignee.Login, p.Milestone.Title, p.Title, p.Comments)
 		}
 	}
-	if err := w.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, "%s", err)
-	}
+	w.Flush()
+	return r.err()
 }
 
-func DisplayIssue(issue *gh.Issue, comments []gh.Comment) {
-	fmt.Fprint(os.Stdout, Green("Issue:"), "\n")
-	fmt.Printf("No: %d\nTitle: %s\n\n", issue.Number, issue.Title)
+// Issue shows an issue with its comments
+func (r *Renderer) Issue(issue *gh.Issue, comments []gh.Comment) error {
+	if r.machine() {
+		record := append(ghIssueRecord(issue, nil),
+			Field{"body", issue.Body},
+			Field{"comments_body", commentRecords(comments)},
+		)
+		return r.records(issue, []Record{record}, true)
+	}
+	fmt.Fprint(r.w, r.green("Issue:"), "\n")
+	fmt.Fprintf(r.w, "No: %d\nTitle: %s\n\n", issue.Number, issue.Title)
 
 	lines := strings.Split(issue.Body, "\n")
 	for i, l := range lines {
 		lines[i] = "\t" + l
 	}
-	fmt.Printf("Description:\n\n%s\n\n", strings.Join(lines, "\n"))
-	fmt.Printf("\n\n")
+	fmt.Fprintf(r.w, "Description:\n\n%s\n\n", strings.Join(lines, "\n"))
+	fmt.Fprintf(r.w, "\n\n")
 
-	DisplayComments(comments)
+	return r.Comments(comments)
 }
 
 // HumanDuration returns a human-readable approximation of a duration
@@ -283,22 +684,178 @@ func HumanDuration(d time.Duration) string {
 	return fmt.Sprintf("%d days", int(d.Hours()/24))
 }
 
-func DisplayPatch(r io.Reader) error {
-	s := bufio.NewScanner(r)
+// Patch colors the lines a patch adds and deletes
+func (r *Renderer) Patch(patch io.Reader) error {
+	s := bufio.NewScanner(patch)
 	for s.Scan() {
 		if err := s.Err(); err != nil {
 			return err
 		}
 		t := s.Text()
+		if t == "" {
+			fmt.Fprintln(r.w)
+			continue
+		}
 
 		switch t[0] {
 		case '-':
-			fmt.Fprintln(os.Stdout, Red(t))
+			fmt.Fprintln(r.w, r.red(t))
 		case '+':
-			fmt.Fprintln(os.Stdout, Green(t))
+			fmt.Fprintln(r.w, r.green(t))
 		default:
-			fmt.Fprintln(os.Stdout, t)
+			fmt.Fprintln(r.w, t)
+		}
+	}
+	return r.err()
+}
+
+// Labels shows the labels of an issue or a pull request after they changed
+func (r *Renderer) Labels(number string, labels []gh.Label) error {
+	if len(labels) == 0 {
+		fmt.Fprintf(r.w, "#%s has no labels\n", number)
+	} else {
+		fmt.Fprintf(r.w, "#%s is labeled %s\n", number, labelsColumn(labels))
+	}
+	return r.err()
+}
+
+// LabelChange tells what a label command would change, for a dry run
+func (r *Renderer) LabelChange(number string, change LabelChange) error {
+	fmt.Fprintf(r.w, "Would %s on #%s\n", change, number)
+	return r.err()
+}
+
+// GrepMatches prints the lines matching a pattern the way grep does, the
+// context lines after a "-" and the groups of lines separated by "--"
+func (r *Renderer) GrepMatches(matches []GrepMatch, context int) error {
+	if r.machine() {
+		records := make([]Record, len(matches))
+		for i, m := range matches {
+			records[i] = Record{
+				{"number", m.Number},
+				{"location", m.Location},
+				{"text", m.Text},
+				{"before", nonNil(m.Before)},
+				{"after", nonNil(m.After)},
+			}
+		}
+		return r.records(matches, records, false)
+	}
+	for i, match := range matches {
+		if context > 0 && i > 0 {
+			fmt.Fprintln(r.w, "--")
+		}
+		for _, line := range match.Before {
+			fmt.Fprintf(r.w, "#%d %s- %s\n", match.Number, match.Location, line)
+		}
+		fmt.Fprintf(r.w, "#%d %s: %s\n", match.Number, r.darkYellow(match.Location), r.highlight(match.Text, match.Matches))
+		for _, line := range match.After {
+			fmt.Fprintf(r.w, "#%d %s- %s\n", match.Number, match.Location, line)
+		}
+	}
+	return r.err()
+}
+
+// highlight colors the parts of `s` between the start and end of each match
+func (r *Renderer) highlight(s string, matches [][]int) string {
+	var (
+		out  string
+		last int
+	)
+	for _, m := range matches {
+		out += s[last:m[0]] + r.red(s[m[0]:m[1]])
+		last = m[1]
+	}
+	return out + s[last:]
+}
+
+// Overlaps lists the pull requests overlapping with `p`, with the number of
+// files and of hunks likely to conflict they share
+func (r *Renderer) Overlaps(p *PullRequest, overlaps []PullRequestOverlap) error {
+	if r.machine() {
+		records := make([]Record, len(overlaps))
+		for i, o := range overlaps {
+			records[i] = Record{
+				{"number", o.PullRequest.Number},
+				{"user", o.PullRequest.User.Login},
+				{"title", o.PullRequest.Title},
+				{"files", o.Files},
+				{"hunks", o.Hunks},
+			}
 		}
+		return r.records(overlaps, records, false)
 	}
-	return nil
+	if len(overlaps) == 0 {
+		fmt.Fprintf(r.w, "No open pull request overlaps with #%d\n", p.Number)
+		return r.err()
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "NUMBER\tCONTRIBUTOR\tTITLE\tFILES\tHUNKS\tSHARED FILES\n")
+	for _, o := range overlaps {
+		title, files := o.PullRequest.Title, strings.Join(o.Files, ",")
+		if !r.NoTrunc {
+			title, files = truncate(title), truncate(files)
+		}
+		hunks := strconv.Itoa(o.Hunks)
+		if o.Hunks > 0 {
+			hunks = r.red(hunks)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", o.PullRequest.Number, o.PullRequest.User.Login, title, len(o.Files), hunks, files)
+	}
+
+	w.Flush()
+	return r.err()
+}
+
+// OverlapMatrix prints the number of files each pair of pull requests share,
+// marked with a * when some of their hunks are likely to conflict
+func (r *Renderer) OverlapMatrix(prs []*PullRequest, matrix [][]Overlap) error {
+	if r.machine() {
+		// a record per pair of overlapping pull requests
+		records := []Record{}
+		for i, p := range prs {
+			for j, o := range matrix[i] {
+				if j <= i || o.Empty() {
+					continue
+				}
+				records = append(records, Record{
+					{"number", p.Number},
+					{"other", prs[j].Number},
+					{"files", o.Files},
+					{"hunks", o.Hunks},
+				})
+			}
+		}
+		return r.records(nil, records, false)
+	}
+	if len(prs) == 0 {
+		fmt.Fprintln(r.w, "No open pull requests overlap")
+		return r.err()
+	}
+	w := r.tabwriter()
+	fmt.Fprintf(w, "NUMBER")
+	for _, p := range prs {
+		fmt.Fprintf(w, "\t%d", p.Number)
+	}
+	fmt.Fprintf(w, "\n")
+	for i, p := range prs {
+		fmt.Fprintf(w, "%d", p.Number)
+		for j, o := range matrix[i] {
+			cell := strconv.Itoa(len(o.Files))
+			switch {
+			case i == j:
+				cell = "-"
+			case o.Empty():
+				cell = "."
+			case o.Hunks > 0:
+				cell += "*"
+			}
+			fmt.Fprintf(w, "\t%s", cell)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	w.Flush()
+	fmt.Fprintln(r.w, "\nshared files, * when the changes are likely to conflict")
+	return r.err()
 }
@@ -0,0 +1,184 @@
+package gordon
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// fixedNow dates the items rendered in the machine formats, which print
+// dates rather than durations
+var fixedNow = time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// testPullRequests are listed by the rendering tests, the tables show their
+// dates relative to now
+func testPullRequests(now time.Time) []*PullRequest {
+	mergeable := true
+	first := &PullRequest{
+		PullRequest: &gh.PullRequest{
+			Number:    1234,
+			Title:     "Fix the race in the fetch workers",
+			Body:      "The workers shared the channel.\nFixes #1200",
+			State:     "open",
+			User:      gh.User{Login: "alice"},
+			Assignee:  &gh.User{Login: "bob"},
+			Head:      gh.Commit{Sha: "0123456789abcdef"},
+			Base:      gh.Commit{Ref: "master"},
+			CreatedAt: now.Add(-10 * 24 * time.Hour),
+			UpdatedAt: now.Add(-3 * 24 * time.Hour),
+			HTMLURL:   "https://github.com/docker/gordon/pull/1234",
+			Additions: 30,
+			Deletions: 12,
+			Mergeable: &mergeable,
+		},
+		Labels:         []gh.Label{{Name: "bug"}, {Name: "priority/P1"}},
+		MergeableState: "clean",
+		Status: &gh.CombinedStatus{
+			State: "failure",
+			Statuses: []gh.Status{
+				{Context: "jenkins", State: "failure", TargetURL: "https://ci.example.com/42"},
+				{Context: "lint", State: "success"},
+			},
+		},
+		Approval: &Approval{
+			Subsystems: []*Subsystem{
+				{Path: "pkg/gordon", Maintainers: []string{"carol"}, Approvers: []string{"carol"}},
+				{Path: "cmd/pulls", Maintainers: []string{"dave"}},
+			},
+			LGTMs: []string{"carol"},
+			Stale: []string{"erin"},
+		},
+	}
+	first.fetched.Full = true
+	second := &PullRequest{
+		PullRequest: &gh.PullRequest{
+			Number:    1240,
+			Title:     "Add a very long title to see how it is truncated in the list of the pull requests",
+			State:     "open",
+			User:      gh.User{Login: "frank"},
+			Head:      gh.Commit{Sha: "fedcba9876543210"},
+			Base:      gh.Commit{Ref: "release-1.0"},
+			CreatedAt: now.Add(-2 * time.Hour),
+			UpdatedAt: now.Add(-2 * time.Hour),
+			HTMLURL:   "https://github.com/docker/gordon/pull/1240",
+		},
+		Draft: true,
+	}
+	return []*PullRequest{first, second}
+}
+
+func testIssues(now time.Time) []*Issue {
+	first := &gh.Issue{
+		Number:    42,
+		Title:     "pulls crashes without a token",
+		State:     "open",
+		User:      gh.User{Login: "alice"},
+		Assignee:  gh.User{Login: "bob"},
+		Labels:    []*gh.Label{{Name: "bug"}},
+		Comments:  3,
+		CreatedAt: now.Add(-30 * 24 * time.Hour),
+		UpdatedAt: now.Add(-5 * 24 * time.Hour),
+		HTMLURL:   "https://github.com/docker/gordon/issues/42",
+	}
+	first.Milestone.Title = "1.0"
+	second := &gh.Issue{
+		Number:    43,
+		Title:     "Support GitHub Enterprise",
+		State:     "open",
+		User:      gh.User{Login: "carol"},
+		CreatedAt: now.Add(-2 * 24 * time.Hour),
+		UpdatedAt: now.Add(-1 * time.Hour),
+		HTMLURL:   "https://github.com/docker/gordon/issues/43",
+	}
+	return []*Issue{{Issue: first, Votes: 4}, {Issue: second}}
+}
+
+func TestPullRequestsTable(t *testing.T) {
+	// the listings print their progress before rendering
+	Progress()
+	ClearProgress()
+
+	var buf bytes.Buffer
+	r := NewRenderer(&buf, RenderOptions{Format: FormatTable, Sizes: DefaultSizes})
+	if err := r.PullRequests(testPullRequests(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two rows, got %q", buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "NUMBER") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	for _, s := range []string{"1234", "01234567", "3 days", "alice", "Fix the race", "bug"} {
+		if !strings.Contains(lines[1], s) {
+			t.Errorf("expected %q in %q", s, lines[1])
+		}
+	}
+	if !strings.Contains(lines[0], "SIZE") || !strings.Contains(lines[0], "CI") {
+		t.Errorf("expected the size and ci columns of #1234 in %q", lines[0])
+	}
+
+	// without --details, neither the sizes nor the statuses are known
+	buf.Reset()
+	prs := testPullRequests(time.Now())[1:]
+	if err := r.PullRequests(prs); err != nil {
+		t.Fatal(err)
+	}
+	if header := strings.SplitN(buf.String(), "\n", 2)[0]; strings.Contains(header, "SIZE") || strings.Contains(header, "CI") {
+		t.Errorf("expected no size nor ci columns, got %q", header)
+	}
+}
+
+// golden compares `got` to testdata/NAME.golden, go test -update rewrites it
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("%s doesn't match, got:\n%s\nexpected:\n%s", path, got, expected)
+	}
+}
+
+func TestRendererGolden(t *testing.T) {
+	for _, format := range []string{FormatTable, FormatJSON, FormatYAML, FormatCSV} {
+		// the tables show durations, the machine formats dates
+		now := fixedNow
+		if format == FormatTable {
+			now = time.Now()
+		}
+		options := RenderOptions{Format: format, Sizes: DefaultSizes, LGTM: true, Votes: true}
+		prs, issues := testPullRequests(now), testIssues(now)
+
+		for name, render := range map[string]func(r *Renderer) error{
+			"pull_requests": func(r *Renderer) error { return r.PullRequests(prs) },
+			"issues":        func(r *Renderer) error { return r.Issues(issues) },
+			"pull_request":  func(r *Renderer) error { return r.PullRequest(prs[0], *prs[0].Status, prs[0].Approval) },
+		} {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := render(NewRenderer(&buf, options)); err != nil {
+					t.Fatal(err)
+				}
+				golden(t, name+"."+format, buf.Bytes())
+			})
+		}
+	}
+}
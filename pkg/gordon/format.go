@@ -0,0 +1,307 @@
+package gordon
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Output formats of the listings
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatCSV   = "csv"
+)
+
+// Formats lists the valid output formats
+var Formats = []string{FormatTable, FormatJSON, FormatYAML, FormatCSV}
+
+// Format is the output format of the listings, see SetFormat
+var Format = FormatTable
+
+// SetFormat validates and selects the output format, one of Formats or a Go
+// template where "\t" and "\n" are a tab and a newline, as they are hard to
+// type in a shell. The machine formats turn off the progress dots and the
+// colors.
+func SetFormat(format string) error {
+	if format == "" {
+		format = FormatTable
+	}
+	for _, f := range Formats {
+		if f == format {
+			Format = format
+			if MachineFormat() {
+				Quiet, Colorize = true, false
+			}
+			return nil
+		}
+	}
+	if strings.Contains(format, "{{") {
+		return SetTemplate(strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format))
+	}
+	return fmt.Errorf("invalid format %q, expected one of %s or a Go template", format, strings.Join(Formats, ", "))
+}
+
+// MachineFormat returns true if the output is meant for scripts rather than
+// for a terminal
+func MachineFormat() bool {
+	return Format != FormatTable
+}
+
+// Field is a named value of a record
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record holds the fields of an item listed in a machine format. The names
+// are stable, snake_case, and their order is kept. Values are nil, strings,
+// numbers, booleans, times, string slices, records or record slices.
+type Record []Field
+
+// MarshalJSON encodes the record as an object keeping the order of the fields
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Map returns the fields of the record, and of the records it holds, by name
+func (r Record) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(r))
+	for _, f := range r {
+		switch v := f.Value.(type) {
+		case Record:
+			m[f.Name] = v.Map()
+		case []Record:
+			maps := make([]map[string]interface{}, len(v))
+			for i, r := range v {
+				maps[i] = r.Map()
+			}
+			m[f.Name] = maps
+		default:
+			m[f.Name] = v
+		}
+	}
+	return m
+}
+
+// WriteRecords encodes the records to `w` in a machine format. A single item
+// is written as a record rather than a list of one with `single`.
+func WriteRecords(w io.Writer, format string, records []Record, single bool) error {
+	switch format {
+	case FormatJSON:
+		var v interface{} = records
+		if single && len(records) == 1 {
+			v = records[0]
+		}
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	case FormatYAML:
+		var buf bytes.Buffer
+		if single && len(records) == 1 {
+			if err := writeYAMLRecord(&buf, records[0], ""); err != nil {
+				return err
+			}
+		} else if err := writeYAMLList(&buf, records, ""); err != nil {
+			return err
+		}
+		_, err := w.Write(buf.Bytes())
+		return err
+	case FormatCSV:
+		return writeCSV(w, records)
+	}
+	return fmt.Errorf("invalid format %q", format)
+}
+
+// records writes `items` with the template of the format, or their records
+// in the other machine formats. Without items, the template is executed for
+// each record, as a map of its fields.
+func (r *Renderer) records(items interface{}, records []Record, single bool) error {
+	if records == nil {
+		records = []Record{}
+	}
+	if r.Format != FormatTemplate {
+		if err := WriteRecords(r.w, r.Format, records, single); err != nil {
+			return err
+		}
+		return r.err()
+	}
+
+	if r.Template == nil {
+		return fmt.Errorf("no template to format the output with")
+	}
+	if items == nil {
+		maps := make([]map[string]interface{}, len(records))
+		for i, record := range records {
+			maps[i] = record.Map()
+		}
+		items = maps
+	}
+	// the colors follow the renderer rather than the terminal
+	t, err := r.Template.Clone()
+	if err != nil {
+		return err
+	}
+	t.Funcs(template.FuncMap{"color": colorFunc(r.Color)})
+	if err := WriteTemplate(r.w, t, items); err != nil {
+		return err
+	}
+	return r.err()
+}
+
+// writeYAMLList writes the records as a block sequence of mappings
+func writeYAMLList(buf *bytes.Buffer, records []Record, indent string) error {
+	if len(records) == 0 {
+		buf.WriteString(indent + "[]\n")
+		return nil
+	}
+	for _, r := range records {
+		buf.WriteString(indent + "- ")
+		if err := writeYAMLRecord(buf, r, indent+"  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLRecord writes a mapping, its first line is expected to be
+// indented already. Scalars are encoded in JSON, which is valid YAML.
+func writeYAMLRecord(buf *bytes.Buffer, r Record, indent string) error {
+	if len(r) == 0 {
+		buf.WriteString("{}\n")
+		return nil
+	}
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteString(indent)
+		}
+		buf.WriteString(f.Name + ":")
+		switch v := f.Value.(type) {
+		case Record:
+			if len(v) == 0 {
+				buf.WriteString(" {}\n")
+				continue
+			}
+			buf.WriteString("\n" + indent + "  ")
+			if err := writeYAMLRecord(buf, v, indent+"  "); err != nil {
+				return err
+			}
+		case []Record:
+			if len(v) == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteString("\n")
+			if err := writeYAMLList(buf, v, indent+"  "); err != nil {
+				return err
+			}
+		case []string:
+			if len(v) == 0 {
+				buf.WriteString(" []\n")
+				continue
+			}
+			buf.WriteString("\n")
+			for _, s := range v {
+				out, _ := json.Marshal(s)
+				buf.WriteString(fmt.Sprintf("%s  - %s\n", indent, out))
+			}
+		default:
+			out, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(" " + string(out) + "\n")
+		}
+	}
+	return nil
+}
+
+// writeCSV writes a header with the names of the fields of the first record,
+// then a row per record. Lists are joined with ";", records are encoded in
+// JSON.
+func writeCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if len(records) > 0 {
+		header := make([]string, len(records[0]))
+		for i, f := range records[0] {
+			header[i] = f.Name
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, r := range records {
+		row := make([]string, len(r))
+		for i, f := range r {
+			row[i] = csvValue(f.Value)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case *bool:
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatBool(*v)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case []string:
+		return strings.Join(v, ";")
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
@@ -3,12 +3,18 @@ package gordon
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	gh "github.com/crosbymichael/octokat"
 )
@@ -20,6 +26,16 @@ type MaintainerManager struct {
 	email      string
 	username   string
 	originPath string
+
+	// statuses caches the final combined status of each head sha, see
+	// ForgetStatuses
+	statusMu sync.Mutex
+	statuses map[string]gh.CombinedStatus
+
+	// maintainers are read once from the MAINTAINERS files
+	maintainersOnce sync.Once
+	maintainers     map[string][]string
+	maintainersErr  error
 }
 
 type Config struct {
@@ -64,6 +80,39 @@ func SaveConfig(config Config) error {
 	return nil
 }
 
+// RepoConfigFileName is the file at the top of the repository holding the
+// settings shared by all of its maintainers
+const RepoConfigFileName = ".gordon.json"
+
+// RepoConfig holds the per repository settings
+type RepoConfig struct {
+	Policy *Policy `json:"policy,omitempty"`
+	Sizes  *Sizes  `json:"sizes,omitempty"`
+}
+
+// LoadRepoConfig reads the RepoConfigFileName of the current repository.
+// An empty configuration is returned if the repository has none.
+func LoadRepoConfig() (*RepoConfig, error) {
+	var config RepoConfig
+	toplevel, err := GetTopLevelGitRepo()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(toplevel, RepoConfigFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &config, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %v", RepoConfigFileName, err)
+	}
+	return &config, nil
+}
+
 func getRepoPath(pth, org string) string {
 	flag := false
 	i := 0
@@ -120,54 +169,112 @@ func NewMaintainerManager(client *gh.Client, org, repo string) (*MaintainerManag
 	}, nil
 }
 
+// Maintainers reads, once, the maintainers of the files of the current
+// repository with their github username
+func (m *MaintainerManager) Maintainers() (map[string][]string, error) {
+	m.maintainersOnce.Do(func() {
+		toplevel, err := GetTopLevelGitRepo()
+		if err != nil {
+			m.maintainersErr = err
+			return
+		}
+		m.maintainers, m.maintainersErr = GetMaintainersFromRepo(toplevel, true)
+	})
+	return m.maintainers, m.maintainersErr
+}
+
 func (m *MaintainerManager) Repository() (*gh.Repository, error) {
 	return m.client.Repository(m.repo, nil)
 }
 
-func (m *MaintainerManager) worker(prepr <-chan *gh.PullRequest, pospr chan<- *gh.PullRequest, wg *sync.WaitGroup, needFullPr, needComments bool) {
-	var err error
+func (m *MaintainerManager) worker(prepr <-chan *PullRequest, pospr chan<- *PullRequest, wg *sync.WaitGroup, need Need) {
 	defer wg.Done()
 
 	for p := range prepr {
-		if needFullPr {
-			p, err = m.GetPullRequest(strconv.Itoa(p.Number))
-			if err != nil {
-				return
-			}
-		}
-		if needComments {
-			p.CommentsBody, err = m.GetComments(strconv.Itoa(p.Number))
-			if err != nil {
-				return
-			}
+		if err := m.fetch(p, need); err != nil {
+			// drop the pull requests we don't know enough about
+			continue
 		}
 		pospr <- p
-		fmt.Printf(".")
+		Progress()
 	}
 }
 
-func (m *MaintainerManager) GetFullPullRequests(prs []*gh.PullRequest, needFullPr, needComments bool) []*gh.PullRequest {
+func (m *MaintainerManager) fetch(p *PullRequest, need Need) error {
 	var (
-		producer      = make(chan *gh.PullRequest, NumWorkers)
-		consumer      = make(chan *gh.PullRequest, NumWorkers)
+		err    error
+		number = strconv.Itoa(p.Number)
+	)
+	// only fetch what wasn't already
+	need.Full = (need.Full || need.Mergeable) && !p.fetched.Full
+	need.Comments = need.Comments && !p.fetched.Comments
+	need.Status = need.Status && !p.fetched.Status
+	if need.Full {
+		full, err := m.GetFullPullRequest(number)
+		if err != nil {
+			return err
+		}
+		// keep the comments if they were fetched first
+		full.CommentsBody = p.CommentsBody
+		p.PullRequest, p.fetched.Full = full.PullRequest, true
+		p.Labels, p.Draft = full.Labels, full.Draft
+		p.MaintainerCanModify, p.MergeableState = full.MaintainerCanModify, full.MergeableState
+	}
+	if need.Comments {
+		// comments come with the reviews and the date of the latest
+		// push so that the votes can be counted
+		if err := m.getVoteData(p); err != nil {
+			return err
+		}
+		p.fetched.Comments = true
+	}
+	if need.Diff && p.Diff == nil {
+		if p.Diff, err = GetDiff(p.PullRequest); err != nil {
+			return err
+		}
+		if p.Files, err = PatchFiles(p.Diff); err != nil {
+			return err
+		}
+	}
+	if need.Status {
+		// a pull request without a status is still worth listing
+		if status, err := m.GetStatus(p.PullRequest); err == nil {
+			p.Status, p.fetched.Status = &status, true
+		}
+	}
+	return nil
+}
+
+func (m *MaintainerManager) GetFullPullRequests(prs []*PullRequest, needFullPr, needComments bool) []*PullRequest {
+	return m.FetchPullRequests(prs, Need{Full: needFullPr, Comments: needComments})
+}
+
+// FetchPullRequests gets the data selected by `need` for each pull request,
+// NumWorkers at a time. The pull requests that fail are left out, the others
+// keep their order.
+func (m *MaintainerManager) FetchPullRequests(prs []*PullRequest, need Need) []*PullRequest {
+	var (
+		producer      = make(chan *PullRequest, NumWorkers)
+		consumer      = make(chan *PullRequest, NumWorkers)
 		wg            = &sync.WaitGroup{}
 		consumerGroup = &sync.WaitGroup{}
-		filteredPrs   = []*gh.PullRequest{}
+		fetched       = make(map[*PullRequest]bool, len(prs))
+		filteredPrs   = []*PullRequest{}
 	)
 
-	// take the finished results and put them into the list
+	// take the finished results and put them into the set
 	consumerGroup.Add(1)
 	go func() {
 		defer consumerGroup.Done()
 
 		for p := range consumer {
-			filteredPrs = append(filteredPrs, p)
+			fetched[p] = true
 		}
 	}()
 
 	for i := 0; i < NumWorkers; i++ {
 		wg.Add(1)
-		go m.worker(producer, consumer, wg, needFullPr, needComments)
+		go m.worker(producer, consumer, wg, need)
 	}
 
 	// add all jobs
@@ -180,14 +287,60 @@ func (m *MaintainerManager) GetFullPullRequests(prs []*gh.PullRequest, needFullP
 	wg.Wait()
 
 	close(consumer)
-	// wait for the consumer to finish adding all the results to the list
+	// wait for the consumer to finish adding all the results to the set
 	consumerGroup.Wait()
 
+	for _, p := range prs {
+		if fetched[p] {
+			filteredPrs = append(filteredPrs, p)
+		}
+	}
+	if need.Mergeable {
+		// the pull requests whose mergeability can't be fetched are
+		// left unknown
+		if err := m.ResolveMergeability(filteredPrs, MergeabilityTimeout); err != nil && VerboseOutput {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
 	return filteredPrs
 }
 
+// LoadReviewers fetches the diff of each pull request, NumWorkers at a time,
+// and finds the reviewers of the files it touches in `maintainers`.
+// Pull requests whose diff can't be fetched are left without reviewers.
+func (m *MaintainerManager) LoadReviewers(prs []*PullRequest, maintainers map[string][]string) {
+	var (
+		jobs = make(chan *PullRequest)
+		wg   = &sync.WaitGroup{}
+	)
+	for i := 0; i < NumWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				if err := m.fetch(p, Need{Diff: true}); err != nil {
+					continue
+				}
+				p.Reviewers, _ = ReviewPatch(p.Diff, maintainers)
+				Progress()
+			}
+		}()
+	}
+	for _, p := range prs {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+}
+
 // Return all pull requests
-func (m *MaintainerManager) GetPullRequests(state, sort string) ([]*gh.PullRequest, error) {
+func (m *MaintainerManager) GetPullRequests(state, sort string) ([]*PullRequest, error) {
+	return m.GetPullRequestsForBase(state, sort, "")
+}
+
+// GetPullRequestsForBase returns the pull requests targeting the branch
+// `base`, or any branch when it is empty
+func (m *MaintainerManager) GetPullRequestsForBase(state, sort, base string) ([]*PullRequest, error) {
 	o := &gh.Options{}
 	o.QueryParams = map[string]string{
 		"sort":      sort,
@@ -195,19 +348,24 @@ func (m *MaintainerManager) GetPullRequests(state, sort string) ([]*gh.PullReque
 		"state":     state,
 		"per_page":  "100",
 	}
+	if base != "" {
+		o.QueryParams["base"] = base
+	}
 	prevSize := -1
 	page := 1
-	allPRs := []*gh.PullRequest{}
+	allPRs := []*PullRequest{}
 	for len(allPRs) != prevSize {
 		o.QueryParams["page"] = strconv.Itoa(page)
-		if prs, err := m.client.PullRequests(m.repo, o); err != nil {
+		// octokat doesn't decode the labels nor the draft state
+		var prs []*PullRequest
+		if err := m.apiRequest("GET", fmt.Sprintf("repos/%s/pulls", m.repo), o.QueryParams, nil, &prs); err != nil {
 			return nil, err
 		} else {
 			prevSize = len(allPRs)
 			allPRs = append(allPRs, prs...)
 			page += 1
 		}
-		fmt.Printf(".")
+		Progress()
 	}
 	return allPRs, nil
 }
@@ -292,7 +450,7 @@ func (m *MaintainerManager) GetIssuesFound(query string) ([]*gh.SearchItem, erro
 			issuesFound = append(issuesFound, issues...)
 			page += 1
 		}
-		fmt.Printf(".")
+		Progress()
 	}
 	return issuesFound, nil
 }
@@ -313,28 +471,158 @@ func (m *MaintainerManager) GetComments(number string) ([]gh.Comment, error) {
 	return m.client.Comments(m.repo, number, nil)
 }
 
+// Return all reviews for a pull request
+// See https://developer.github.com/v3/pulls/reviews/#list-reviews-on-a-pull-request
+func (m *MaintainerManager) GetReviews(number string) ([]Review, error) {
+	var (
+		all  []Review
+		page = 1
+	)
+	for {
+		var reviews []Review
+		query := map[string]string{
+			"per_page": "100",
+			"page":     strconv.Itoa(page),
+		}
+		if err := m.apiRequest("GET", fmt.Sprintf("repos/%s/pulls/%s/reviews", m.repo, number), query, nil, &reviews); err != nil {
+			return nil, err
+		}
+		all = append(all, reviews...)
+		if len(reviews) < 100 {
+			return all, nil
+		}
+		page++
+	}
+}
+
 // Add a comment to an existing pull request
 func (m *MaintainerManager) AddComment(number, comment string) (gh.Comment, error) {
 	return m.client.AddComment(m.repo, number, comment)
 }
 
-// Merge a pull request
-// If no LGTMs are in the comments require force to be true
-func (m *MaintainerManager) MergePullRequest(number, comment string, force bool) (gh.Merge, error) {
-	comments, err := m.GetComments(number)
+// Return the diff of a pull request
+func GetDiff(pr *gh.PullRequest) ([]byte, error) {
+	resp, err := http.Get(pr.DiffURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// GetVotes returns the votes left on a pull request through its reviews and comments
+func (m *MaintainerManager) GetVotes(pr *gh.PullRequest) ([]Vote, error) {
+	p := &PullRequest{PullRequest: pr}
+	if err := m.getVoteData(p); err != nil {
+		return nil, err
+	}
+	return p.Votes(), nil
+}
+
+// getVoteData fetches the comments, reviews and latest push of a pull request
+func (m *MaintainerManager) getVoteData(p *PullRequest) error {
+	var (
+		err    error
+		number = strconv.Itoa(p.Number)
+	)
+	if p.CommentsBody, err = m.GetComments(number); err != nil {
+		return err
+	}
+	if p.Reviews, err = m.GetReviews(number); err != nil {
+		return err
+	}
+	p.PushedAt, err = m.GetPushedAt(p.PullRequest)
+	return err
+}
+
+// GetPushedAt returns the commit date of the head of a pull request.
+// It's used as the date of the latest push to the pull request.
+func (m *MaintainerManager) GetPushedAt(pr *gh.PullRequest) (time.Time, error) {
+	commit, err := m.client.Commit(m.repo, pr.Head.Sha, nil)
 	if err != nil {
-		return gh.Merge{}, err
+		return time.Time{}, err
 	}
-	isApproved := false
-	for _, c := range comments {
-		// FIXME: Again should check for LGTM from a maintainer
-		if strings.Contains(c.Body, "LGTM") {
-			isApproved = true
-			break
+	if commit.Commit == nil || commit.Commit.Committer.Date == nil {
+		return time.Time{}, nil
+	}
+	return *commit.Commit.Committer.Date, nil
+}
+
+// GetApproval returns which of the subsystems touched by the pull request were
+// approved by their maintainers
+func (m *MaintainerManager) GetApproval(pr *gh.PullRequest) (*Approval, error) {
+	diff, err := GetDiff(pr)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := m.GetVotes(pr)
+	if err != nil {
+		return nil, err
+	}
+	return GetApprovalForPR(diff, votes)
+}
+
+// GetPolicy returns the merge policy of the repository
+func (m *MaintainerManager) GetPolicy() (Policy, error) {
+	config, err := LoadRepoConfig()
+	if err != nil {
+		return Policy{}, err
+	}
+	if config.Policy == nil {
+		return DefaultPolicy, nil
+	}
+	return *config.Policy, nil
+}
+
+// EvaluatePolicy checks the pull request against the merge policy of the repository
+func (m *MaintainerManager) EvaluatePolicy(pr *gh.PullRequest) (*PolicyResult, error) {
+	policy, err := m.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := GetDiff(pr)
+	if err != nil {
+		return nil, err
+	}
+	subsystems, err := GetSubsystemsForPR(diff)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := m.GetVotes(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	var status gh.CombinedStatus
+	if policy.RequireCI {
+		if status, err = m.GetStatus(pr); err != nil {
+			return nil, err
 		}
 	}
-	if !isApproved && !force {
-		return gh.Merge{}, fmt.Errorf("Pull request %s has not been approved", number)
+	var leads []string
+	if policy.LeadForMaintainers {
+		if leads, err = GetLeads(policy); err != nil {
+			return nil, err
+		}
+	}
+	return policy.Evaluate(pr, subsystems, votes, status, leads), nil
+}
+
+// Merge a pull request
+// If the pull request doesn't satisfy the merge policy require force to be true
+func (m *MaintainerManager) MergePullRequest(number, comment string, force bool) (gh.Merge, error) {
+	if !force {
+		pr, err := m.GetPullRequest(number)
+		if err != nil {
+			return gh.Merge{}, err
+		}
+		result, err := m.EvaluatePolicy(pr)
+		if err != nil {
+			return gh.Merge{}, err
+		}
+		if !result.Passed() {
+			return gh.Merge{}, fmt.Errorf("Pull request %s does not satisfy the merge policy:\n%s", number, result.Error())
+		}
 	}
 	o := &gh.Options{}
 	o.Params = map[string]string{
@@ -448,12 +736,12 @@ func (m *MaintainerManager) GetFirstIssue(state, sortBy string) (*gh.Issue, erro
 }
 
 // GetIssues queries the GithubAPI for all issues matching the state `state` and the
-// assignee `assignee`.
+// assignee `assignee`, sorted by `sort`: created, updated or comments.
 // See http://developer.github.com/v3/issues/#list-issues-for-a-repository
-func (m *MaintainerManager) GetIssues(state, assignee string) ([]*gh.Issue, error) {
+func (m *MaintainerManager) GetIssues(state, assignee, sort string) ([]*Issue, error) {
 	o := &gh.Options{}
 	o.QueryParams = map[string]string{
-		"sort":      "updated",
+		"sort":      sort,
 		"direction": "asc",
 		"state":     state,
 		"per_page":  "100",
@@ -465,17 +753,19 @@ func (m *MaintainerManager) GetIssues(state, assignee string) ([]*gh.Issue, erro
 	}
 	prevSize := -1
 	page := 1
-	all := []*gh.Issue{}
+	all := []*Issue{}
 	for len(all) != prevSize {
 		o.QueryParams["page"] = strconv.Itoa(page)
-		if issues, err := m.client.Issues(m.repo, o); err != nil {
+		// octokat doesn't decode the reactions
+		var issues []*Issue
+		if err := m.apiRequest("GET", fmt.Sprintf("repos/%s/issues", m.repo), o.QueryParams, nil, &issues); err != nil {
 			return nil, err
 		} else {
 			prevSize = len(all)
 			all = append(all, issues...)
 			page += 1
 		}
-		fmt.Printf(".")
+		Progress()
 	}
 	return all, nil
 }
@@ -495,10 +785,169 @@ func GenBranchName(text string) string {
 	return branchName
 }
 
-// GetStatus queries the GithubAPI for the current build status of a pull request
-// See http://developer.github.com/v3/issues/#list-issues-for-a-repository
+// GetStatus queries the GithubAPI for the current build status of a pull request.
+// The status of each head sha is only fetched once it is final: success,
+// failure or error. A pending one is fetched again.
+// See https://developer.github.com/v3/repos/statuses/#get-the-combined-status-for-a-specific-ref
 func (m *MaintainerManager) GetStatus(pr *gh.PullRequest) (gh.CombinedStatus, error) {
+	m.statusMu.Lock()
+	status, cached := m.statuses[pr.Head.Sha]
+	m.statusMu.Unlock()
+	if cached {
+		return status, nil
+	}
+
 	o := &gh.Options{}
 	o.QueryParams = map[string]string{}
-	return m.client.CombinedStatus(m.repo, pr.Head.Sha, o)
+	status, err := m.client.CombinedStatus(m.repo, pr.Head.Sha, o)
+	if err != nil {
+		return status, err
+	}
+
+	switch status.State {
+	case CISuccess, CIFailure, CIError:
+		m.statusMu.Lock()
+		if m.statuses == nil {
+			m.statuses = make(map[string]gh.CombinedStatus)
+		}
+		m.statuses[pr.Head.Sha] = status
+		m.statusMu.Unlock()
+	}
+	return status, nil
+}
+
+// ForgetStatuses drops the statuses cached by GetStatus, a check run again
+// changes the status of a head
+func (m *MaintainerManager) ForgetStatuses() {
+	m.statusMu.Lock()
+	m.statuses = nil
+	m.statusMu.Unlock()
+}
+
+// GetRequestedReviewers returns the login of every user that has been asked to
+// review a pull request. The issue events are used instead of the pending
+// review requests because GitHub drops a request as soon as the review is left.
+func (m *MaintainerManager) GetRequestedReviewers(number string) ([]string, error) {
+	var (
+		requested = make(map[string]bool)
+		page      = 1
+	)
+	for {
+		var events []struct {
+			Event             string  `json:"event"`
+			RequestedReviewer gh.User `json:"requested_reviewer"`
+		}
+		query := map[string]string{
+			"per_page": "100",
+			"page":     strconv.Itoa(page),
+		}
+		if err := m.apiRequest("GET", fmt.Sprintf("repos/%s/issues/%s/events", m.repo, number), query, nil, &events); err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			switch e.Event {
+			case "review_requested":
+				requested[e.RequestedReviewer.Login] = true
+			case "review_request_removed":
+				delete(requested, e.RequestedReviewer.Login)
+			}
+		}
+		if len(events) < 100 {
+			break
+		}
+		page++
+	}
+
+	logins := []string{}
+	for login := range requested {
+		if login != "" {
+			logins = append(logins, login)
+		}
+	}
+	sort.Strings(logins)
+	return logins, nil
+}
+
+// RequestReviewers asks each of the users in `logins` to review a pull request
+// See https://developer.github.com/v3/pulls/review_requests/#create-a-review-request
+func (m *MaintainerManager) RequestReviewers(number string, logins []string) error {
+	body := map[string][]string{
+		"reviewers": logins,
+	}
+	return m.apiRequest("POST", fmt.Sprintf("repos/%s/pulls/%s/requested_reviewers", m.repo, number), nil, body, nil)
+}
+
+// AddLabels adds labels to an issue or a pull request and returns all of its labels
+// See https://developer.github.com/v3/issues/labels/#add-labels-to-an-issue
+func (m *MaintainerManager) AddLabels(number string, labels []string) ([]gh.Label, error) {
+	var all []gh.Label
+	if err := m.apiRequest("POST", fmt.Sprintf("repos/%s/issues/%s/labels", m.repo, number), nil, labels, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// RemoveLabels removes labels from an issue or a pull request and returns the
+// labels left
+// See https://developer.github.com/v3/issues/labels/#remove-a-label-from-an-issue
+func (m *MaintainerManager) RemoveLabels(number string, labels []string) ([]gh.Label, error) {
+	var left []gh.Label
+	for _, label := range labels {
+		if err := m.apiRequest("DELETE", fmt.Sprintf("repos/%s/issues/%s/labels/%s", m.repo, number, url.PathEscape(label)), nil, nil, &left); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+// Take assigns the pull request to the authenticated user, leaving a
+// "#assignee=" comment. Without the permission to assign, the user is added
+// as "#volunteer" instead and assigned is false. A pull request assigned to
+// someone else is only taken with `steal`.
+func (m *MaintainerManager) Take(number string, steal bool) (user *gh.User, assigned bool, err error) {
+	pr, err := m.GetPullRequest(number)
+	if err != nil {
+		return nil, false, err
+	}
+	if user, err = m.GetGithubUser(); err != nil {
+		return nil, false, err
+	}
+	if user == nil {
+		return nil, false, ErrNoUsernameKnown
+	}
+	if pr.Assignee != nil && !steal {
+		return user, false, fmt.Errorf("Use --steal to steal the PR from %s", pr.Assignee.Login)
+	}
+	pr.Assignee = user
+	patchedPR, err := m.PatchPullRequest(number, pr)
+	if err != nil {
+		return user, false, err
+	}
+	if patchedPR.Assignee == nil || patchedPR.Assignee.Login != user.Login {
+		_, err = m.AddComment(number, "#volunteer")
+		return user, false, err
+	}
+	_, err = m.AddComment(number, fmt.Sprintf("#assignee=%s", patchedPR.Assignee.Login))
+	return user, true, err
+}
+
+// Drop unassigns a pull request assigned to the authenticated user
+func (m *MaintainerManager) Drop(number string) error {
+	pr, err := m.GetPullRequest(number)
+	if err != nil {
+		return err
+	}
+	user, err := m.GetGithubUser()
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrNoUsernameKnown
+	}
+	if pr.Assignee == nil || pr.Assignee.Login != user.Login {
+		return fmt.Errorf("Can't drop %s: it's not yours.", number)
+	}
+	pr.Assignee = nil
+	_, err = m.PatchPullRequest(number, pr)
+	return err
 }
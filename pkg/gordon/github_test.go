@@ -0,0 +1,52 @@
+package gordon
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+func TestGetStatus(t *testing.T) {
+	var (
+		states   = map[string]string{"pending": "pending", "success": "success"}
+		requests = map[string]int{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sha := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repos/docker/docker/commits/"), "/status")
+		requests[sha]++
+		fmt.Fprintf(w, `{"state": %q, "sha": %q}`, states[sha], sha)
+	}))
+	defer server.Close()
+	m := testManager(server)
+
+	get := func(sha string) string {
+		status, err := m.GetStatus(&gh.PullRequest{Head: gh.Commit{Sha: sha}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status.State
+	}
+	for i := 0; i < 2; i++ {
+		get("pending")
+		get("success")
+	}
+	if requests["pending"] != 2 || requests["success"] != 1 {
+		t.Errorf("expected a pending status fetched each time and a final one once, got %v", requests)
+	}
+
+	// the checks passed
+	states["pending"] = CISuccess
+	if state := get("pending"); state != CISuccess {
+		t.Errorf("expected the new state, got %q", state)
+	}
+
+	m.ForgetStatuses()
+	get("success")
+	if requests["success"] != 2 {
+		t.Errorf("expected the statuses to be fetched again once forgotten, got %v", requests)
+	}
+}
@@ -0,0 +1,149 @@
+package gordon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GrepMatch is a line of a pull request matching a pattern
+type GrepMatch struct {
+	Number int
+	// Location is where the line is: "title", "body", "comment by LOGIN",
+	// "review by LOGIN" or "FILE:LINE" in the diff
+	Location string
+	Text     string
+	// Matches holds the start and end of each match in Text
+	Matches [][]int
+	// Before and After are the lines around Text, the context
+	Before []string
+	After  []string
+}
+
+// Grep returns the lines of the title, body, comments, reviews and diff of the
+// pull request matching `re`, with `context` lines around them. The comments
+// and diff are only searched if they were fetched.
+func (p *PullRequest) Grep(re *regexp.Regexp, context int) []GrepMatch {
+	var out []GrepMatch
+	search := func(lines []string, location func(i int) string) {
+		for i, line := range lines {
+			matches := re.FindAllStringIndex(line, -1)
+			if matches == nil {
+				continue
+			}
+			out = append(out, GrepMatch{
+				Number:   p.Number,
+				Location: location(i),
+				Text:     line,
+				Matches:  matches,
+				Before:   lines[max(0, i-context):i],
+				After:    lines[i+1 : min(len(lines), i+1+context)],
+			})
+		}
+	}
+	at := func(location string) func(int) string {
+		return func(int) string { return location }
+	}
+
+	search([]string{p.Title}, at("title"))
+	search(splitLines(p.Body), at("body"))
+	for _, c := range p.CommentsBody {
+		search(splitLines(c.Body), at("comment by "+c.User.Login))
+	}
+	for _, r := range p.Reviews {
+		search(splitLines(r.Body), at("review by "+r.User.Login))
+	}
+	if p.Diff != nil {
+		lines, locations := diffLocations(string(p.Diff))
+		search(lines, func(i int) string { return locations[i] })
+	}
+	return out
+}
+
+// diffLocations splits a diff in lines and returns the location of each, as
+// FILE:LINE in the new version of the file, or the old one for the deletions
+func diffLocations(diff string) ([]string, []string) {
+	var (
+		lines     = splitLines(diff)
+		locations = make([]string, len(lines))
+		file      string
+		old, new  int
+		// header is true between "diff --git" and the first hunk of a
+		// file, "--- " and "+++ " are changed lines in the hunks
+		header bool
+	)
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, old, new, header = "", 0, 0, true
+		case header && strings.HasPrefix(line, "--- "):
+			if name := strings.TrimPrefix(line, "--- "); name != "/dev/null" {
+				file = strings.TrimPrefix(name, "a/")
+			}
+		case header && strings.HasPrefix(line, "+++ "):
+			if name := strings.TrimPrefix(line, "+++ "); name != "/dev/null" {
+				file = strings.TrimPrefix(name, "b/")
+			}
+		case strings.HasPrefix(line, "@@ "):
+			old, new = hunkStart(line)
+			header = false
+			locations[i] = file
+			continue
+		case strings.HasPrefix(line, "-") && old > 0:
+			locations[i] = fmt.Sprintf("%s:%d", file, old)
+			old++
+			continue
+		case strings.HasPrefix(line, "+") && new > 0:
+			locations[i] = fmt.Sprintf("%s:%d", file, new)
+			new++
+			continue
+		case strings.HasPrefix(line, " ") && new > 0:
+			locations[i] = fmt.Sprintf("%s:%d", file, new)
+			old, new = old+1, new+1
+			continue
+		}
+		locations[i] = file
+	}
+	return lines, locations
+}
+
+// hunkStart returns the first old and new lines of a hunk header such as
+// "@@ -12,7 +12,8 @@ func main() {"
+func hunkStart(header string) (int, int) {
+	var old, new int
+	for _, field := range strings.Fields(header)[1:] {
+		if field == "@@" {
+			break
+		}
+		n, _ := strconv.Atoi(strings.SplitN(field[1:], ",", 2)[0])
+		if field[0] == '-' {
+			old = n
+		} else {
+			new = n
+		}
+	}
+	return old, new
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,38 @@
+package gordon
+
+import "testing"
+
+func TestDiffLocations(t *testing.T) {
+	diff := `diff --git a/schema.sql b/schema.sql
+index 1111111..2222222 100644
+--- a/schema.sql
++++ b/schema.sql
+@@ -3,3 +3,3 @@ CREATE TABLE users (
+ id INT,
+--- the name of the user
+++++ counter
+ name TEXT
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,1 @@
++package main
+`
+	lines, locations := diffLocations(diff)
+	expected := map[string]string{
+		" id INT,":                 "schema.sql:3",
+		"--- the name of the user": "schema.sql:4",
+		"++++ counter":             "schema.sql:4",
+		" name TEXT":               "schema.sql:5",
+		"+package main":            "new.go:1",
+	}
+	for i, line := range lines {
+		if location, exists := expected[line]; exists && locations[i] != location {
+			t.Errorf("%q: expected %s, got %s", line, location, locations[i])
+		}
+	}
+	if len(lines) != len(locations) {
+		t.Errorf("expected a location per line")
+	}
+}
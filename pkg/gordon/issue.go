@@ -0,0 +1,103 @@
+package gordon
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// Reactions sums up the reactions left on an issue
+// See https://developer.github.com/v3/reactions/
+type Reactions struct {
+	TotalCount int `json:"total_count"`
+	PlusOne    int `json:"+1"`
+	MinusOne   int `json:"-1"`
+}
+
+// Issue wraps the issue returned by octokat with the fields it doesn't decode
+type Issue struct {
+	*gh.Issue
+	Reactions Reactions `json:"reactions"`
+
+	// Votes is the number of 👍 reactions, or of +1 comments on the legacy
+	// threads without any reaction, see LoadVotes
+	Votes int `json:"-"`
+}
+
+// plusOneRegexp matches a line voting +1, quoted text left aside
+var plusOneRegexp = regexp.MustCompile(`^\s*(\+1|:\+1:|👍)([\s.!]|$)`)
+
+// IsPlusOne returns true if a comment votes +1 on one of its lines, not
+// counting the quoted ones nor numbers such as +10
+func IsPlusOne(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if plusOneRegexp.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountPlusOnes returns the number of users who voted +1 in the comments
+func CountPlusOnes(comments []gh.Comment) int {
+	voters := make(map[string]bool)
+	for _, c := range comments {
+		if IsPlusOne(c.Body) {
+			voters[c.User.Login] = true
+		}
+	}
+	return len(voters)
+}
+
+// LoadVotes counts the votes of each issue: its 👍 reactions, or the +1
+// comments of the threads older than reactions. The comments are fetched
+// NumWorkers issues at a time.
+func (m *MaintainerManager) LoadVotes(issues []*Issue) error {
+	var (
+		jobs     = make(chan *Issue)
+		wg       = &sync.WaitGroup{}
+		errMu    sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < NumWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for issue := range jobs {
+				comments, err := m.GetComments(strconv.Itoa(issue.Number))
+				if err != nil {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errMu.Unlock()
+					continue
+				}
+				issue.Votes = CountPlusOnes(comments)
+				Progress()
+			}
+		}()
+	}
+	for _, issue := range issues {
+		issue.Votes = issue.Reactions.PlusOne
+		if issue.Votes == 0 && issue.Comments > 0 {
+			jobs <- issue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// Vote adds a 👍 reaction to an issue
+// See https://developer.github.com/v3/reactions/#create-reaction-for-an-issue
+func (m *MaintainerManager) Vote(number string) error {
+	body := map[string]string{
+		"content": "+1",
+	}
+	return m.apiRequest("POST", fmt.Sprintf("repos/%s/issues/%s/reactions", m.repo, number), nil, body, nil)
+}
@@ -0,0 +1,102 @@
+package gordon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+func TestIsPlusOne(t *testing.T) {
+	for _, test := range []struct {
+		body     string
+		expected bool
+	}{
+		{"+1", true},
+		{":+1:", true},
+		{"👍", true},
+		{"+1!", true},
+		{"  +1 for this", true},
+		{"I agree\n+1.", true},
+		{"+10", false},
+		{"+1000 on this", false},
+		{"> +1", false},
+		{"> +1\nwhy?", false},
+		{"not +1", false},
+		{"", false},
+	} {
+		if IsPlusOne(test.body) != test.expected {
+			t.Errorf("%q: expected %t", test.body, test.expected)
+		}
+	}
+}
+
+func TestCountPlusOnes(t *testing.T) {
+	comment := func(login, body string) gh.Comment {
+		return gh.Comment{User: gh.User{Login: login}, Body: body}
+	}
+	for _, test := range []struct {
+		comments []gh.Comment
+		expected int
+	}{
+		{nil, 0},
+		{[]gh.Comment{comment("alice", "+1"), comment("bob", ":+1:")}, 2},
+		// a user voting twice is counted once
+		{[]gh.Comment{comment("alice", "+1"), comment("alice", "+1 again")}, 1},
+		// neither numbers nor quoted votes are counted
+		{[]gh.Comment{comment("alice", "+10"), comment("bob", "> +1\nI don't")}, 0},
+		{[]gh.Comment{comment("alice", "> +1\n+1"), comment("bob", "+10")}, 1},
+	} {
+		if n := CountPlusOnes(test.comments); n != test.expected {
+			t.Errorf("%+v: expected %d votes, got %d", test.comments, test.expected, n)
+		}
+	}
+}
+
+func TestLoadVotes(t *testing.T) {
+	fetched := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched <- r.URL.Path
+		switch r.URL.Path {
+		case "/repos/docker/docker/issues/2/comments":
+			w.Write([]byte(`[
+				{"user": {"login": "alice"}, "body": "+1"},
+				{"user": {"login": "alice"}, "body": "+1"},
+				{"user": {"login": "bob"}, "body": "+10"},
+				{"user": {"login": "carol"}, "body": "> +1\nnot for me"},
+				{"user": {"login": "dave"}, "body": "👍"}
+			]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	defer server.Close()
+	m := testManager(server)
+
+	issues := []*Issue{
+		// the reactions are counted, not the comments
+		{Issue: &gh.Issue{Number: 1, Comments: 4}, Reactions: Reactions{PlusOne: 3}},
+		// a legacy thread has its +1 comments counted
+		{Issue: &gh.Issue{Number: 2, Comments: 5}},
+		// there is nothing to fetch without any comment
+		{Issue: &gh.Issue{Number: 3}},
+	}
+	if err := m.LoadVotes(issues); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []int{3, 2, 0} {
+		if issues[i].Votes != expected {
+			t.Errorf("#%d: expected %d votes, got %d", issues[i].Number, expected, issues[i].Votes)
+		}
+	}
+	close(fetched)
+	var paths []string
+	for path := range fetched {
+		paths = append(paths, path)
+	}
+	if len(paths) != 1 || paths[0] != "/repos/docker/docker/issues/2/comments" {
+		t.Errorf("expected to fetch only the comments of #2, got %q", paths)
+	}
+}
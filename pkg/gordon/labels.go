@@ -0,0 +1,66 @@
+package gordon
+
+import (
+	"fmt"
+	"strings"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// LabelChange adds labels to issues and pull requests, or removes them
+type LabelChange struct {
+	Remove bool
+	Names  []string
+}
+
+func (l LabelChange) String() string {
+	verb := "add"
+	if l.Remove {
+		verb = "rm"
+	}
+	return fmt.Sprintf("%s %s", verb, strings.Join(l.Names, ", "))
+}
+
+// ParseLabelArgs parses the arguments of the label commands,
+// "[ID] add|rm NAME...". The number is empty when the issues or pull requests
+// are to be selected by filters.
+func ParseLabelArgs(args []string) (string, LabelChange, error) {
+	var number string
+	if len(args) > 0 && args[0] != "add" && args[0] != "rm" {
+		number, args = args[0], args[1:]
+	}
+	if len(args) < 2 || (args[0] != "add" && args[0] != "rm") {
+		return "", LabelChange{}, fmt.Errorf("usage: label ID add|rm NAME... or label --FILTER... add|rm NAME...")
+	}
+	return number, LabelChange{Remove: args[0] == "rm", Names: args[1:]}, nil
+}
+
+// ChangeLabels applies a change to an issue or a pull request and returns the
+// labels it is left with
+func (m *MaintainerManager) ChangeLabels(number string, change LabelChange) ([]gh.Label, error) {
+	if change.Remove {
+		return m.RemoveLabels(number, change.Names)
+	}
+	return m.AddLabels(number, change.Names)
+}
+
+// LabelAll applies a change to each of `numbers`, rendering the labels each is
+// left with, or only what would change with `dryRun`
+func (m *MaintainerManager) LabelAll(r *Renderer, numbers []string, change LabelChange, dryRun bool) error {
+	for _, number := range numbers {
+		if dryRun {
+			if err := r.LabelChange(number, change); err != nil {
+				return err
+			}
+			continue
+		}
+		labels, err := m.ChangeLabels(number, change)
+		if err != nil {
+			return err
+		}
+		if err := r.Labels(number, labels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
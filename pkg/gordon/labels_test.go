@@ -0,0 +1,41 @@
+package gordon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseLabelArgs(t *testing.T) {
+	for _, test := range []struct {
+		args   []string
+		number string
+		change string
+		valid  bool
+	}{
+		{[]string{"12", "add", "bug"}, "12", "add bug", true},
+		{[]string{"rm", "bug", "wip"}, "", "rm bug, wip", true},
+		{[]string{"12", "add"}, "", "", false},
+		{[]string{"12", "set", "bug"}, "", "", false},
+		{nil, "", "", false},
+	} {
+		number, change, err := ParseLabelArgs(test.args)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: expected valid to be %t, got %v", test.args, test.valid, err)
+			continue
+		}
+		if test.valid && (number != test.number || change.String() != test.change) {
+			t.Errorf("%q: expected %q and %q, got %q and %q", test.args, test.number, test.change, number, change)
+		}
+	}
+}
+
+func TestLabelAllDryRun(t *testing.T) {
+	var buf bytes.Buffer
+	m := &MaintainerManager{}
+	if err := m.LabelAll(NewRenderer(&buf, RenderOptions{}), []string{"1", "2"}, LabelChange{Names: []string{"bug"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Would add bug on #1\nWould add bug on #2\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
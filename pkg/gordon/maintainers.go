@@ -112,3 +112,61 @@ func buildFileIndex(maintainers map[string][]string) map[string]map[string]bool
 	}
 	return index
 }
+
+// OwnershipTree is a directory tree of the repository annotated with the
+// maintainers owning each path
+type OwnershipTree struct {
+	Name        string
+	Path        string
+	Maintainers []string
+	// Inherited is true when the maintainers are declared by a parent directory
+	Inherited bool
+	Children  []*OwnershipTree
+}
+
+// GetOwnershipTree walks the repository at `repoPath` and returns its directories,
+// as well as the files that have their own maintainers, with their effective
+// maintainers. `maintainers` is the result of GetMaintainersFromRepo.
+// The walk stops at `maxDepth` levels unless it's 0.
+func GetOwnershipTree(repoPath string, maintainers map[string][]string, maxDepth int) (*OwnershipTree, error) {
+	var (
+		index = buildFileIndex(maintainers)
+		root  = &OwnershipTree{Name: ".", Path: "."}
+	)
+	root.Maintainers = maintainerLogins(index["."])
+	if err := buildOwnershipTree(repoPath, root, index, 1, maxDepth); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func buildOwnershipTree(repoPath string, parent *OwnershipTree, index map[string]map[string]bool, depth, maxDepth int) error {
+	if maxDepth > 0 && depth > maxDepth {
+		return nil
+	}
+	contents, err := ioutil.ReadDir(filepath.Join(repoPath, parent.Path))
+	if err != nil {
+		return err
+	}
+	for _, fi := range contents {
+		p := filepath.ToSlash(filepath.Join(parent.Path, fi.Name()))
+		declared := index[p]
+		if fi.Name() == ".git" || (!fi.IsDir() && len(declared) == 0) {
+			continue
+		}
+		node := &OwnershipTree{Name: fi.Name(), Path: p}
+		if len(declared) > 0 {
+			node.Maintainers = maintainerLogins(declared)
+		} else {
+			node.Maintainers = parent.Maintainers
+			node.Inherited = true
+		}
+		if fi.IsDir() {
+			if err := buildOwnershipTree(repoPath, node, index, depth+1, maxDepth); err != nil {
+				return err
+			}
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return nil
+}
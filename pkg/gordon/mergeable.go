@@ -0,0 +1,108 @@
+package gordon
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Mergeable states, as computed by GitHub along with the mergeability
+const (
+	// MergeableClean can be merged, every check passed
+	MergeableClean = "clean"
+	// MergeableDirty conflicts with its base
+	MergeableDirty = "dirty"
+	// MergeableBlocked is blocked by the protection of its base
+	MergeableBlocked = "blocked"
+	// MergeableBehind is out of date with its base
+	MergeableBehind = "behind"
+	// MergeableUnstable can be merged but some checks didn't pass
+	MergeableUnstable = "unstable"
+	// MergeableUnknown is still being computed
+	MergeableUnknown = "unknown"
+)
+
+// MergeableStates lists the mergeable states the pull requests can be filtered by
+var MergeableStates = []string{MergeableClean, MergeableDirty, MergeableBlocked, MergeableBehind, MergeableUnstable, MergeableUnknown}
+
+// MergeabilityTimeout is how long ResolveMergeability waits for GitHub to
+// compute the mergeability of the pull requests
+var MergeabilityTimeout = 30 * time.Second
+
+// GetFullPullRequest returns a pull request with its mergeability and size,
+// which the list of pull requests doesn't include
+func (m *MaintainerManager) GetFullPullRequest(number string) (*PullRequest, error) {
+	// octokat doesn't decode the mergeable state
+	var p PullRequest
+	if err := m.apiRequest("GET", fmt.Sprintf("repos/%s/pulls/%s", m.repo, number), nil, nil, &p); err != nil {
+		return nil, err
+	}
+	p.fetched.Full = true
+	return &p, nil
+}
+
+// ResolveMergeability polls the pull requests whose mergeability GitHub is
+// still computing, backing off between the rounds, until it is known or
+// `timeout` expires. The pull requests still unknown by then are left as is.
+// It stops at the first round failing to fetch one of them, returning its
+// error.
+func (m *MaintainerManager) ResolveMergeability(prs []*PullRequest, timeout time.Duration) error {
+	var (
+		deadline = time.Now().Add(timeout)
+		delay    = time.Second
+	)
+	for {
+		var pending []*PullRequest
+		for _, p := range prs {
+			if !p.Merged && p.Mergeable == nil {
+				pending = append(pending, p)
+			}
+		}
+		if len(pending) == 0 || time.Now().Add(delay).After(deadline) {
+			return nil
+		}
+		time.Sleep(delay)
+		if delay < 8*time.Second {
+			delay *= 2
+		}
+		if err := m.refreshMergeability(pending); err != nil {
+			return err
+		}
+	}
+}
+
+// refreshMergeability fetches the mergeability of the pull requests again,
+// NumWorkers at a time, and returns the first error
+func (m *MaintainerManager) refreshMergeability(prs []*PullRequest) error {
+	var (
+		jobs     = make(chan *PullRequest)
+		wg       = &sync.WaitGroup{}
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < NumWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				full, err := m.GetFullPullRequest(strconv.Itoa(p.Number))
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("can't fetch the mergeability of #%d: %v", p.Number, err)
+					}
+					mu.Unlock()
+					continue
+				}
+				p.Mergeable, p.MergeableState = full.Mergeable, full.MergeableState
+			}
+		}()
+	}
+	for _, p := range prs {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,52 @@
+package gordon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// testManager returns a manager of docker/docker talking to `server`
+func testManager(server *httptest.Server) *MaintainerManager {
+	client := gh.NewClient()
+	client.BaseURL = server.URL
+	return &MaintainerManager{repo: gh.Repo{UserName: "docker", Name: "docker"}, client: client}
+}
+
+func TestResolveMergeability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/docker/docker/pulls/1":
+			w.Write([]byte(`{"number": 1, "mergeable": true, "mergeable_state": "clean"}`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+		}
+	}))
+	defer server.Close()
+	m := testManager(server)
+
+	prs := []*PullRequest{
+		{PullRequest: &gh.PullRequest{Number: 1}},
+		{PullRequest: &gh.PullRequest{Number: 2}},
+	}
+	err := m.ResolveMergeability(prs, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "#2: GET repos/docker/docker/pulls/2: API rate limit exceeded") {
+		t.Fatalf("expected the error of #2, got %v", err)
+	}
+	if prs[0].Mergeable == nil || !*prs[0].Mergeable || prs[0].MergeableState != MergeableClean {
+		t.Errorf("expected #1 to be resolved, got %v %q", prs[0].Mergeable, prs[0].MergeableState)
+	}
+	if prs[1].Mergeable != nil {
+		t.Errorf("expected #2 to be left unknown")
+	}
+
+	// nothing is fetched once they are known
+	if err := m.ResolveMergeability(prs[:1], time.Minute); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,194 @@
+package gordon
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fkautz/codereview/patch"
+)
+
+// Overlap is what two pull requests both change
+type Overlap struct {
+	// Files are the files both pull requests touch
+	Files []string
+	// Hunks is the number of hunks of the first pull request changing the
+	// same or adjacent lines as the other, which are likely to conflict
+	Hunks int
+}
+
+// Empty returns true if the pull requests don't touch the same files
+func (o Overlap) Empty() bool {
+	return len(o.Files) == 0
+}
+
+// lineRange is the lines [start, end) of a file a hunk replaces
+type lineRange struct {
+	start, end int
+}
+
+func (r lineRange) touches(o lineRange) bool {
+	// adjacent changes conflict too
+	return r.start <= o.end && o.start <= r.end
+}
+
+// Overlaps returns the files and hunks `p` and `o` both change. Their diffs
+// must have been fetched. When one of them can't be parsed, the files are
+// returned along with the error.
+func (p *PullRequest) Overlaps(o *PullRequest) (Overlap, error) {
+	var overlap Overlap
+	files := make(map[string]bool, len(o.Files))
+	for _, f := range o.Files {
+		files[f] = true
+	}
+	for _, f := range p.Files {
+		if files[f] {
+			overlap.Files = append(overlap.Files, f)
+		}
+	}
+	if overlap.Empty() {
+		return overlap, nil
+	}
+
+	hunks, err := p.loadHunks()
+	if err != nil {
+		return overlap, err
+	}
+	others, err := o.loadHunks()
+	if err != nil {
+		return overlap, err
+	}
+	for file, ranges := range hunks {
+		for _, r := range ranges {
+			for _, other := range others[file] {
+				if r.touches(other) {
+					overlap.Hunks++
+					break
+				}
+			}
+		}
+	}
+	return overlap, nil
+}
+
+// loadHunks parses the lines of each file of the base the diff replaces
+func (p *PullRequest) loadHunks() (map[string][]lineRange, error) {
+	if p.hunks != nil {
+		return p.hunks, nil
+	}
+	set, err := patch.Parse(p.Diff)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse the diff of #%d: %s", p.Number, strings.TrimSpace(err.Error()))
+	}
+	hunks := make(map[string][]lineRange)
+	for _, f := range set.File {
+		diff, ok := f.Diff.(patch.TextDiff)
+		if !ok {
+			continue
+		}
+		// the lines are those of the file in the base
+		name := f.Dst
+		if f.Src != "" {
+			name = f.Src
+		}
+		for _, chunk := range diff {
+			lines := 0
+			for _, b := range chunk.Old {
+				if b == '\n' {
+					lines++
+				}
+			}
+			hunks[name] = append(hunks[name], lineRange{chunk.Line, chunk.Line + lines})
+		}
+	}
+	p.hunks = hunks
+	return hunks, nil
+}
+
+// PullRequestOverlap is a pull request overlapping with another one
+type PullRequestOverlap struct {
+	PullRequest *PullRequest
+	Overlap
+}
+
+// FindOverlaps returns the pull requests of `prs` overlapping with `p`, the
+// most likely to conflict first
+func FindOverlaps(p *PullRequest, prs []*PullRequest) []PullRequestOverlap {
+	var (
+		out    []PullRequestOverlap
+		warned = make(map[string]bool)
+	)
+	for _, o := range prs {
+		if o.Number == p.Number {
+			continue
+		}
+		if overlap := overlaps(p, o, warned); !overlap.Empty() {
+			out = append(out, PullRequestOverlap{o, overlap})
+		}
+	}
+	sort.Stable(byConflicts(out))
+	return out
+}
+
+// overlaps only counts the shared files of the pull requests whose diff
+// can't be parsed, warning once about each of them
+func overlaps(p, o *PullRequest, warned map[string]bool) Overlap {
+	overlap, err := p.Overlaps(o)
+	if err != nil && !warned[err.Error()] {
+		warned[err.Error()] = true
+		fmt.Fprintf(os.Stderr, "%v, only counting the files it shares\n", err)
+	}
+	return overlap
+}
+
+// byConflicts sorts the overlaps by hunks, then files, the largest first
+type byConflicts []PullRequestOverlap
+
+func (a byConflicts) Len() int      { return len(a) }
+func (a byConflicts) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byConflicts) Less(i, j int) bool {
+	if a[i].Hunks != a[j].Hunks {
+		return a[j].Hunks < a[i].Hunks
+	}
+	return len(a[j].Files) < len(a[i].Files)
+}
+
+// OverlapMatrix returns the pull requests of `prs` overlapping with at least
+// another one, and the overlap of each pair of them
+func OverlapMatrix(prs []*PullRequest) ([]*PullRequest, [][]Overlap) {
+	var (
+		all    = make([][]Overlap, len(prs))
+		warned = make(map[string]bool)
+	)
+	for i, p := range prs {
+		all[i] = make([]Overlap, len(prs))
+		for j, o := range prs {
+			if i != j {
+				all[i][j] = overlaps(p, o, warned)
+			}
+		}
+	}
+
+	var kept []int
+	for i := range prs {
+		for j := range prs {
+			if !all[i][j].Empty() {
+				kept = append(kept, i)
+				break
+			}
+		}
+	}
+	var (
+		out    = make([]*PullRequest, len(kept))
+		matrix = make([][]Overlap, len(kept))
+	)
+	for i, k := range kept {
+		out[i] = prs[k]
+		matrix[i] = make([]Overlap, len(kept))
+		for j, l := range kept {
+			matrix[i][j] = all[k][l]
+		}
+	}
+	return out, matrix
+}
@@ -0,0 +1,48 @@
+package gordon
+
+import (
+	"testing"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+func diffPullRequest(number int, diff string, files ...string) *PullRequest {
+	return &PullRequest{PullRequest: &gh.PullRequest{Number: number}, Diff: []byte(diff), Files: files}
+}
+
+const mainDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -10,2 +10,2 @@
+-a
+-b
++c
++d
+`
+
+func TestOverlapMatrixSkipsUnparsableDiffs(t *testing.T) {
+	broken := diffPullRequest(3, "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -x +y @@\n-a\n", "main.go")
+	if _, err := broken.loadHunks(); err == nil {
+		t.Fatal("expected the diff not to parse")
+	}
+	prs := []*PullRequest{
+		diffPullRequest(1, mainDiff, "main.go"),
+		diffPullRequest(2, mainDiff, "main.go"),
+		broken,
+	}
+	kept, matrix := OverlapMatrix(prs)
+	if len(kept) != 3 {
+		t.Fatalf("expected the 3 pull requests to overlap, got %d", len(kept))
+	}
+	if o := matrix[0][1]; o.Hunks != 1 || len(o.Files) != 1 {
+		t.Errorf("expected #1 and #2 to share a file and a hunk, got %+v", o)
+	}
+	if o := matrix[0][2]; o.Hunks != 0 || len(o.Files) != 1 {
+		t.Errorf("expected #1 and #3 to only share a file, got %+v", o)
+	}
+
+	overlaps := FindOverlaps(prs[0], prs)
+	if len(overlaps) != 2 || overlaps[0].PullRequest.Number != 2 {
+		t.Errorf("expected #2 then #3 to overlap with #1, got %+v", overlaps)
+	}
+}
@@ -0,0 +1,113 @@
+package gordon
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/moby/term"
+)
+
+// DefaultPager pages when neither $GORDON_PAGER nor $PAGER are set
+const DefaultPager = "less -R"
+
+// NoPager writes the long views straight to the standard output
+var NoPager bool
+
+// PagerCommand returns the pager from $GORDON_PAGER, then $PAGER, then
+// DefaultPager. It is empty when paging is disabled with --no-pager, with an
+// empty $GORDON_PAGER or with "cat".
+func PagerCommand() string {
+	if NoPager {
+		return ""
+	}
+	pager, set := os.LookupEnv("GORDON_PAGER")
+	if !set {
+		if pager, set = os.LookupEnv("PAGER"); !set {
+			pager = DefaultPager
+		}
+	}
+	if pager == "cat" {
+		return ""
+	}
+	return pager
+}
+
+// Pager writes to the input of the pager, or to the standard output when
+// there is none
+type Pager struct {
+	w   io.WriteCloser
+	cmd *exec.Cmd
+	// done is true once the pager quit, what follows is dropped
+	done bool
+	// interrupts catches ^C while the pager runs
+	interrupts chan os.Signal
+}
+
+// StartPager starts the pager when the standard output is a terminal, like
+// git does. Without a pager, or when it can't be started, the pager writes to
+// the standard output.
+func StartPager() *Pager {
+	pager := PagerCommand()
+	if pager == "" || !term.IsTerminal(os.Stdout.Fd()) {
+		return &Pager{w: os.Stdout}
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = os.Environ()
+	if _, set := os.LookupEnv("LESS"); !set {
+		// like git: quit when it fits the screen, keep the colors and
+		// the screen
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return &Pager{w: os.Stdout}
+	}
+	PrintVerboseCommand(cmd)
+	if err := cmd.Start(); err != nil {
+		return &Pager{w: os.Stdout}
+	}
+	// like git: ^C is for the pager, which gets it too, we keep writing
+	// until it quits
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	return &Pager{w: w, cmd: cmd, interrupts: interrupts}
+}
+
+func (p *Pager) Write(b []byte) (int, error) {
+	if p.done {
+		return len(b), nil
+	}
+	n, err := p.w.Write(b)
+	if p.cmd != nil && errors.Is(err, syscall.EPIPE) {
+		// the rest wasn't wanted
+		p.done = true
+		return len(b), nil
+	}
+	return n, err
+}
+
+// Close waits for the pager to quit
+func (p *Pager) Close() error {
+	if p.cmd == nil {
+		return nil
+	}
+	p.w.Close()
+	err := p.cmd.Wait()
+	signal.Stop(p.interrupts)
+	return err
+}
+
+// Paged renders a long view with `options` through the pager, see StartPager
+func Paged(options RenderOptions, view func(*Renderer) error) error {
+	pager := StartPager()
+	err := view(NewRenderer(pager, options))
+	if closeErr := pager.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
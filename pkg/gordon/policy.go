@@ -0,0 +1,243 @@
+package gordon
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// Policy describes the approvals a pull request needs before it can be merged.
+// It is read from the "policy" section of the repository configuration, e.g.
+//
+//	{
+//		"policy": {
+//			"approvals": 2,
+//			"lead_for_maintainers": true,
+//			"no_self_approval": true,
+//			"require_ci": true
+//		}
+//	}
+type Policy struct {
+	// Approvals is the number of LGTMs needed from the maintainers of each
+	// subsystem touched by the pull request, defaults to 1
+	Approvals int `json:"approvals"`
+	// LeadForMaintainers requires one of the leads to approve any change
+	// to a MAINTAINERS file
+	LeadForMaintainers bool `json:"lead_for_maintainers"`
+	// Leads overrides the maintainers of the top-most MAINTAINERS file
+	Leads []string `json:"leads,omitempty"`
+	// NoSelfApproval ignores the LGTM of the author of the pull request
+	NoSelfApproval bool `json:"no_self_approval"`
+	// RequireCI requires the combined status of the head commit to be a success
+	RequireCI bool `json:"require_ci"`
+}
+
+// DefaultPolicy is used by repositories without a policy: one LGTM from the
+// maintainers of every subsystem
+var DefaultPolicy = Policy{Approvals: 1}
+
+// Rule is the outcome of one of the rules of a policy
+type Rule struct {
+	Name   string
+	Passed bool
+	Reason string
+}
+
+// PolicyResult is the outcome of every rule of a policy for a pull request
+type PolicyResult struct {
+	Approval *Approval
+	Rules    []Rule
+}
+
+// Passed returns true when every rule passed
+func (r *PolicyResult) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures returns the rules that did not pass
+func (r *PolicyResult) Failures() []Rule {
+	var failures []Rule
+	for _, rule := range r.Rules {
+		if !rule.Passed {
+			failures = append(failures, rule)
+		}
+	}
+	return failures
+}
+
+// Error explains every unmet rule, one per line
+func (r *PolicyResult) Error() string {
+	var reasons []string
+	for _, rule := range r.Failures() {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", rule.Name, rule.Reason))
+	}
+	return strings.Join(reasons, "\n")
+}
+
+// Evaluate checks the pull request against the policy. `subsystems` are the
+// subsystems touched by the pull request, `votes` the votes of its reviewers,
+// `status` the combined status of its head and `leads` the logins of the
+// project leads.
+func (p Policy) Evaluate(pr *gh.PullRequest, subsystems []*Subsystem, votes []Vote, status gh.CombinedStatus, leads []string) *PolicyResult {
+	if p.Approvals < 1 {
+		p.Approvals = 1
+	}
+	if p.NoSelfApproval {
+		var others []Vote
+		for _, v := range votes {
+			if v.Login != pr.User.Login {
+				others = append(others, v)
+			}
+		}
+		votes = others
+	}
+
+	result := &PolicyResult{Approval: ApproveSubsystems(subsystems, votes)}
+	result.Approval.blockLeads(leads)
+	result.Rules = append(result.Rules, p.approvalRule(result.Approval), changesRule(result.Approval))
+	if p.LeadForMaintainers {
+		result.Rules = append(result.Rules, p.leadRule(result.Approval, leads))
+	}
+	if p.RequireCI {
+		result.Rules = append(result.Rules, ciRule(status))
+	}
+	return result
+}
+
+func (p Policy) approvalRule(approval *Approval) Rule {
+	rule := Rule{Name: "approvals", Passed: len(approval.Subsystems) > 0}
+	if !rule.Passed {
+		rule.Reason = "the pull request does not change any file"
+		return rule
+	}
+
+	var missing []string
+	for _, s := range approval.Subsystems {
+		if len(s.Approvers) >= p.Approvals {
+			continue
+		}
+		rule.Passed = false
+		if len(s.Maintainers) == 0 {
+			missing = append(missing, fmt.Sprintf("%s has %d/%d LGTM", s.Path, len(s.Approvers), p.Approvals))
+		} else {
+			missing = append(missing, fmt.Sprintf("%s has %d/%d LGTM from %s", s.Path, len(s.Approvers), p.Approvals, strings.Join(s.Maintainers, ", ")))
+		}
+	}
+	if rule.Passed {
+		rule.Reason = fmt.Sprintf("%d LGTM from the maintainers of every subsystem", p.Approvals)
+	} else {
+		rule.Reason = strings.Join(missing, "; ")
+		if len(approval.Stale) > 0 {
+			rule.Reason += fmt.Sprintf("; approvals from %s are stale, new commits were pushed since", strings.Join(approval.Stale, ", "))
+		}
+	}
+	return rule
+}
+
+func (p Policy) leadRule(approval *Approval, leads []string) Rule {
+	rule := Rule{Name: "lead", Passed: true, Reason: "no MAINTAINERS file is changed"}
+
+	var files []string
+	for _, s := range approval.Subsystems {
+		for _, f := range s.Files {
+			if path.Base(f) == MaintainerFileName {
+				files = append(files, f)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return rule
+	}
+
+	isLead := make(map[string]bool, len(leads))
+	for _, l := range leads {
+		isLead[l] = true
+	}
+	for _, login := range approval.LGTMs {
+		if isLead[login] {
+			rule.Reason = fmt.Sprintf("changes to %s approved by lead %s", strings.Join(files, ", "), login)
+			return rule
+		}
+	}
+	rule.Passed = false
+	rule.Reason = fmt.Sprintf("changes to %s need an LGTM from one of the leads: %s", strings.Join(files, ", "), strings.Join(leads, ", "))
+	return rule
+}
+
+// blockLeads moves the rejections of the leads to the blockers
+func (a *Approval) blockLeads(leads []string) {
+	isLead := make(map[string]bool, len(leads))
+	for _, l := range leads {
+		isLead[l] = true
+	}
+	var others []string
+	for _, login := range a.Rejections {
+		if isLead[login] {
+			a.Blockers = append(a.Blockers, login)
+		} else {
+			others = append(others, login)
+		}
+	}
+	a.Rejections = others
+	sort.Strings(a.Blockers)
+}
+
+func changesRule(approval *Approval) Rule {
+	if len(approval.Blockers) > 0 {
+		return Rule{Name: "changes", Reason: fmt.Sprintf("rejected or changes requested by %s", strings.Join(approval.Blockers, ", "))}
+	}
+	if len(approval.Rejections) > 0 {
+		return Rule{Name: "changes", Passed: true, Reason: fmt.Sprintf("no changes requested by the maintainers, only by %s", strings.Join(approval.Rejections, ", "))}
+	}
+	return Rule{Name: "changes", Passed: true, Reason: "no changes requested"}
+}
+
+func ciRule(status gh.CombinedStatus) Rule {
+	rule := Rule{Name: "ci", Passed: status.State == "success"}
+	switch {
+	case rule.Passed:
+		rule.Reason = "all status checks passed"
+	case status.State == "":
+		rule.Reason = "no status reported"
+	default:
+		rule.Reason = fmt.Sprintf("status is %s", status.State)
+	}
+	return rule
+}
+
+// GetLeads returns the usernames of the project leads: the ones listed in the
+// policy or else the maintainers of the top-most MAINTAINERS file.
+func GetLeads(p Policy) ([]string, error) {
+	if len(p.Leads) > 0 {
+		return p.Leads, nil
+	}
+	toplevel, err := GetTopLevelGitRepo()
+	if err != nil {
+		return nil, err
+	}
+	file, err := TopMostMaintainerFile(toplevel)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		leads []string
+		seen  = make(map[string]bool)
+	)
+	for _, maintainers := range file {
+		for _, m := range maintainers {
+			login := m.Username
+			if login == "" {
+				login = m.Email
+			}
+			if m.Lead && m.Active && !seen[login] {
+				seen[login] = true
+				leads = append(leads, login)
+			}
+		}
+	}
+	sort.Strings(leads)
+	return leads, nil
+}
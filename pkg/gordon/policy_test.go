@@ -0,0 +1,75 @@
+package gordon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	subsystems := func() []*Subsystem {
+		return []*Subsystem{
+			{Path: ".", Files: []string{"README.md"}, Maintainers: []string{"alice", "dave"}},
+			{Path: "daemon", Files: []string{"daemon/daemon.go"}, Maintainers: []string{"bob", "carol"}},
+		}
+	}
+	withMaintainersFile := func() []*Subsystem {
+		s := subsystems()
+		s[1].Files = append(s[1].Files, "daemon/MAINTAINERS")
+		return s
+	}
+	approve := func(logins ...string) []Vote {
+		var votes []Vote
+		for _, login := range logins {
+			votes = append(votes, Vote{Login: login, Approved: true})
+		}
+		return votes
+	}
+	leads := []string{"lead"}
+
+	for _, test := range []struct {
+		name       string
+		policy     Policy
+		author     string
+		subsystems []*Subsystem
+		votes      []Vote
+		status     string
+		// failures are the names of the rules failing followed by their
+		// reasons
+		failures []string
+	}{
+		{"default", DefaultPolicy, "erin", subsystems(), approve("alice", "bob"), "", nil},
+		{"zero approvals means one", Policy{}, "erin", subsystems(), approve("alice", "bob"), "", nil},
+		{"missing subsystem", DefaultPolicy, "erin", subsystems(), approve("alice"), "",
+			[]string{"approvals: daemon has 0/1 LGTM from bob, carol"}},
+		{"no files", DefaultPolicy, "erin", nil, approve("alice"), "",
+			[]string{"approvals: the pull request does not change any file"}},
+		{"two approvals", Policy{Approvals: 2}, "erin", subsystems(), approve("alice", "bob", "carol"), "",
+			[]string{"approvals: . has 1/2 LGTM from alice, dave"}},
+		{"stale approvals", DefaultPolicy, "erin", subsystems(), append(approve("alice"), Vote{Login: "bob", Approved: true, Stale: true}), "",
+			[]string{"approvals: daemon has 0/1 LGTM from bob, carol; approvals from bob are stale, new commits were pushed since"}},
+		{"self approval", Policy{NoSelfApproval: true}, "alice", subsystems(), approve("alice", "bob"), "",
+			[]string{"approvals: . has 0/1 LGTM from alice, dave"}},
+		{"self approval allowed", DefaultPolicy, "alice", subsystems(), approve("alice", "bob"), "", nil},
+		{"changes requested", DefaultPolicy, "erin", subsystems(), append(approve("alice", "bob"), Vote{Login: "carol"}), "",
+			[]string{"changes: rejected or changes requested by carol"}},
+		{"lead missing", Policy{LeadForMaintainers: true}, "erin", withMaintainersFile(), approve("alice", "bob"), "",
+			[]string{"lead: changes to daemon/MAINTAINERS need an LGTM from one of the leads: lead"}},
+		{"lead approved", Policy{LeadForMaintainers: true}, "erin", withMaintainersFile(), approve("alice", "bob", "lead"), "", nil},
+		{"lead without maintainers file", Policy{LeadForMaintainers: true}, "erin", subsystems(), approve("alice", "bob"), "", nil},
+		{"ci passed", Policy{RequireCI: true}, "erin", subsystems(), approve("alice", "bob"), CISuccess, nil},
+		{"ci pending", Policy{RequireCI: true}, "erin", subsystems(), approve("alice", "bob"), CIPending,
+			[]string{"ci: status is pending"}},
+		{"ci missing", Policy{RequireCI: true}, "erin", subsystems(), approve("alice", "bob"), "",
+			[]string{"ci: no status reported"}},
+		{"ci ignored", DefaultPolicy, "erin", subsystems(), approve("alice", "bob"), CIFailure, nil},
+	} {
+		result := test.policy.Evaluate(testPR(test.author), test.subsystems, test.votes, testStatus(test.status), leads)
+		var failures []string
+		if !result.Passed() {
+			failures = strings.Split(result.Error(), "\n")
+		}
+		if strings.Join(failures, "\n") != strings.Join(test.failures, "\n") {
+			t.Errorf("%s: expected the failures %q, got %q", test.name, test.failures, failures)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package gordon
+
+import (
+	"regexp"
+	"strings"
+)
+
+// NoPriority is the priority of the issues and pull requests without a
+// priority label, after all the others
+const NoPriority = 100
+
+// priorityRegexp matches the priority labels, e.g. "priority/P1", "P0" or
+// "priority/high"
+var priorityRegexp = regexp.MustCompile(`(?i)^(?:priority\s*[/:-]?\s*)?(p[0-9]|critical|urgent|high|medium|low)$`)
+
+var priorityWords = map[string]int{
+	"critical": 0,
+	"urgent":   0,
+	"high":     1,
+	"medium":   2,
+	"low":      3,
+}
+
+// Priority returns the most urgent priority found in the names of the labels,
+// 0 being the most urgent, or NoPriority
+func Priority(labels []string) int {
+	priority := NoPriority
+	for _, label := range labels {
+		match := priorityRegexp.FindStringSubmatch(strings.TrimSpace(label))
+		if match == nil {
+			continue
+		}
+		value := strings.ToLower(match[1])
+		p, isWord := priorityWords[value]
+		if !isWord {
+			p = int(value[1] - '0')
+		}
+		if p < priority {
+			priority = p
+		}
+	}
+	return priority
+}
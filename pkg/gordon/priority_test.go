@@ -0,0 +1,28 @@
+package gordon
+
+import "testing"
+
+func TestPriority(t *testing.T) {
+	for _, test := range []struct {
+		labels   []string
+		expected int
+	}{
+		{nil, NoPriority},
+		{[]string{"bug", "kind/enhancement"}, NoPriority},
+		{[]string{"P1"}, 1},
+		{[]string{"p3"}, 3},
+		{[]string{"priority/P0"}, 0},
+		{[]string{"priority/high"}, 1},
+		{[]string{"Priority: Medium"}, 2},
+		{[]string{"priority-low"}, 3},
+		{[]string{"critical"}, 0},
+		// the most urgent label wins
+		{[]string{"priority/low", "bug", "P1"}, 1},
+		// only whole labels are priorities
+		{[]string{"P10", "highlight", "priority/highest"}, NoPriority},
+	} {
+		if p := Priority(test.labels); p != test.expected {
+			t.Errorf("%q: expected %d, got %d", test.labels, test.expected, p)
+		}
+	}
+}
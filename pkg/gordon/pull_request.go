@@ -0,0 +1,213 @@
+package gordon
+
+import (
+	"strings"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// PullRequest wraps the pull request returned by octokat with the fields it
+// doesn't decode and the data gordon gathers about it from other endpoints
+type PullRequest struct {
+	*gh.PullRequest
+	Labels []gh.Label `json:"labels,omitempty"`
+	Draft  bool       `json:"draft,omitempty"`
+	// MaintainerCanModify is true when the maintainers can push to the
+	// branch of the pull request
+	MaintainerCanModify bool `json:"maintainer_can_modify,omitempty"`
+	// MergeableState tells why a pull request can or can't be merged, it's
+	// only known with the mergeability, see ResolveMergeability
+	MergeableState string `json:"mergeable_state,omitempty"`
+
+	Reviews []Review `json:"-"`
+	// PushedAt is the commit date of the head, the closest we get to
+	// the date of the latest push
+	PushedAt time.Time `json:"-"`
+	// Approval is only computed when the approvals are displayed or filtered
+	Approval *Approval `json:"-"`
+	// Diff and Files are fetched when needed, Reviewers are filled by LoadReviewers
+	Diff      []byte              `json:"-"`
+	Files     []string            `json:"-"`
+	Reviewers map[string][]string `json:"-"`
+	// Status is the combined status of the head
+	Status *gh.CombinedStatus `json:"-"`
+
+	// fetched is what was already fetched for the pull request
+	fetched Need
+	// hunks are the lines of the base the diff changes, see Overlaps
+	hunks map[string][]lineRange
+}
+
+// Need selects the data FetchPullRequests gets for each pull request
+type Need struct {
+	// Full fetches the pull request itself, the list doesn't include its
+	// mergeability nor its size
+	Full bool
+	// Comments fetches the comments, the reviews and the latest push
+	Comments bool
+	// Diff fetches the diff and the files it touches
+	Diff bool
+	// Status fetches the combined status of the head
+	Status bool
+	// Mergeable fetches the full pull requests and waits for GitHub to
+	// compute their mergeability, see ResolveMergeability
+	Mergeable bool
+	// Maintainers reads the MAINTAINERS files of the repository, nothing
+	// is fetched for it
+	Maintainers bool
+}
+
+// Merge returns the data needed by either n or o
+func (n Need) Merge(o Need) Need {
+	return Need{
+		Full:        n.Full || o.Full,
+		Comments:    n.Comments || o.Comments,
+		Diff:        n.Diff || o.Diff,
+		Status:      n.Status || o.Status,
+		Mergeable:   n.Mergeable || o.Mergeable,
+		Maintainers: n.Maintainers || o.Maintainers,
+	}
+}
+
+// Any returns true if anything needs to be fetched
+func (n Need) Any() bool {
+	return n.Full || n.Comments || n.Diff || n.Status || n.Mergeable
+}
+
+// ReviewedBy returns true if `maintainer` is a reviewer of one of the files
+// touched by the pull request. LoadReviewers must have been called.
+func (p *PullRequest) ReviewedBy(maintainer string) bool {
+	for _, fileReviewers := range p.Reviewers {
+		for _, r := range fileReviewers {
+			if r == maintainer {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasLabel returns true if the pull request has the label, ignoring case
+func (p *PullRequest) HasLabel(name string) bool {
+	for _, l := range p.Labels {
+		if strings.EqualFold(l.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromFork returns true if the branch of the pull request lives in another
+// repository, including the forks that were deleted since
+func (p *PullRequest) FromFork() bool {
+	return p.Head.Repo.FullName != p.Base.Repo.FullName
+}
+
+// Lines returns the number of lines added and deleted by the pull request, it
+// is only known once the full pull request was fetched
+func (p *PullRequest) Lines() (int, bool) {
+	return p.Additions + p.Deletions, p.fetched.Full
+}
+
+// CI states
+const (
+	CISuccess = "success"
+	CIFailure = "failure"
+	CIError   = "error"
+	CIPending = "pending"
+)
+
+// CIState returns the state reported for the head of the pull request by the
+// status `context`, or the combined state of every context when it is empty.
+// It is empty when nothing was reported or the status wasn't fetched.
+func (p *PullRequest) CIState(context string) string {
+	if p.Status == nil || len(p.Status.Statuses) == 0 {
+		return ""
+	}
+	if context == "" {
+		return p.Status.State
+	}
+	// the combined status holds the latest status of each context
+	for _, s := range p.Status.Statuses {
+		if s.Context == context {
+			return s.State
+		}
+	}
+	return ""
+}
+
+// LastActivity returns when the author of the pull request, or any other
+// contributor who isn't a maintainer, and when a maintainer last commented,
+// reviewed or pushed. The author is active from the opening of the pull
+// request, the maintainer time is zero when none of them took part yet. The
+// comments must have been fetched.
+func (p *PullRequest) LastActivity(isMaintainer func(login string) bool) (author, maintainer time.Time) {
+	author = p.CreatedAt
+	if p.PushedAt.After(author) {
+		author = p.PushedAt
+	}
+	record := func(login string, at time.Time) {
+		if login != p.User.Login && isMaintainer(login) {
+			if at.After(maintainer) {
+				maintainer = at
+			}
+		} else if at.After(author) {
+			author = at
+		}
+	}
+	for _, c := range p.CommentsBody {
+		record(c.User.Login, c.CreatedAt)
+	}
+	for _, r := range p.Reviews {
+		record(r.User.Login, r.SubmittedAt)
+	}
+	return author, maintainer
+}
+
+// LoadApproval computes, once, the approval of the pull request by the
+// maintainers. The comments and the diff must have been fetched.
+func (p *PullRequest) LoadApproval(maintainers map[string][]string) (*Approval, error) {
+	if p.Approval != nil {
+		return p.Approval, nil
+	}
+	subsystems, err := PatchSubsystems(p.Diff, maintainers)
+	if err != nil {
+		return nil, err
+	}
+	p.Approval = ApproveSubsystems(subsystems, p.Votes())
+	return p.Approval, nil
+}
+
+// Votes returns the votes left through the reviews and comments of the pull request
+func (p *PullRequest) Votes() []Vote {
+	return CollectVotes(p.CommentsBody, p.Reviews, p.Head.Sha, p.PushedAt)
+}
+
+// Review states
+const (
+	ReviewApproved         = "APPROVED"
+	ReviewChangesRequested = "CHANGES_REQUESTED"
+	ReviewCommented        = "COMMENTED"
+	ReviewDismissed        = "DISMISSED"
+)
+
+// Review is a review left on a pull request through the GitHub review UI
+// See https://developer.github.com/v3/pulls/reviews/
+type Review struct {
+	ID          int       `json:"id"`
+	User        gh.User   `json:"user"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	CommitID    string    `json:"commit_id"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// WrapPullRequests wraps each of the pull requests returned by octokat
+func WrapPullRequests(prs []*gh.PullRequest) []*PullRequest {
+	out := make([]*PullRequest, len(prs))
+	for i, p := range prs {
+		out[i] = &PullRequest{PullRequest: p}
+	}
+	return out
+}
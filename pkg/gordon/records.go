@@ -0,0 +1,240 @@
+package gordon
+
+import (
+	"sort"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// The records are what the machine formats list, their field names are part
+// of the interface of the commands: add fields, don't rename them.
+
+// timeValue leaves the dates that are unknown empty
+func timeValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func labelNames(labels []gh.Label) []string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names
+}
+
+func pullRequestRecord(p *PullRequest, ciContext string, sizes Sizes) Record {
+	var (
+		assignee interface{}
+		lines    interface{}
+		size     interface{}
+		ci       interface{}
+	)
+	if p.Assignee != nil {
+		assignee = p.Assignee.Login
+	}
+	if n, ok := p.Lines(); ok {
+		lines, size = n, sizes.Label(n)
+	}
+	if state := p.CIState(ciContext); state != "" {
+		ci = state
+	}
+	return Record{
+		{"number", p.Number},
+		{"title", p.Title},
+		{"user", p.User.Login},
+		{"assignee", assignee},
+		{"state", p.State},
+		{"draft", p.Draft},
+		{"base", p.Base.Ref},
+		{"head_sha", p.Head.Sha},
+		{"created_at", timeValue(p.CreatedAt)},
+		{"updated_at", timeValue(p.UpdatedAt)},
+		{"labels", labelNames(p.Labels)},
+		{"lines", lines},
+		{"size", size},
+		{"ci", ci},
+		{"mergeable", p.Mergeable},
+		{"mergeable_state", p.MergeableState},
+		{"approval", approvalRecord(p.Approval)},
+		{"url", p.HTMLURL},
+	}
+}
+
+// approvalRecord is nil when the approval wasn't computed
+func approvalRecord(a *Approval) interface{} {
+	if a == nil {
+		return nil
+	}
+	pending := []string{}
+	for _, s := range a.Pending() {
+		pending = append(pending, s.Path)
+	}
+	return Record{
+		{"approved", a.Approved()},
+		{"approvers", nonNil(a.Approvers())},
+		{"blockers", nonNil(a.Blockers)},
+		{"rejections", nonNil(a.Rejections)},
+		{"stale", nonNil(a.Stale)},
+		{"pending", pending},
+	}
+}
+
+// nonNil lists nothing as an empty list rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func statusRecords(status gh.CombinedStatus) []Record {
+	records := []Record{}
+	for _, s := range status.Statuses {
+		records = append(records, Record{
+			{"context", s.Context},
+			{"state", s.State},
+			{"target_url", s.TargetURL},
+		})
+	}
+	return records
+}
+
+func commentRecords(comments []gh.Comment) []Record {
+	records := []Record{}
+	for _, c := range comments {
+		records = append(records, Record{
+			{"user", c.User.Login},
+			{"created_at", timeValue(c.CreatedAt)},
+			{"body", c.Body},
+		})
+	}
+	return records
+}
+
+// issueRecord takes the fields the issue types have in common, votes is nil
+// when they weren't counted
+func issueRecord(number int, title, user, assignee, milestone, state string, labels []string, comments int, votes interface{}, created, updated time.Time, url string) Record {
+	var assigned, milestoned interface{}
+	if assignee != "" {
+		assigned = assignee
+	}
+	if milestone != "" {
+		milestoned = milestone
+	}
+	return Record{
+		{"number", number},
+		{"title", title},
+		{"user", user},
+		{"assignee", assigned},
+		{"milestone", milestoned},
+		{"state", state},
+		{"labels", nonNil(labels)},
+		{"comments", comments},
+		{"votes", votes},
+		{"created_at", timeValue(created)},
+		{"updated_at", timeValue(updated)},
+		{"url", url},
+	}
+}
+
+func ghIssueRecord(p *gh.Issue, votes interface{}) Record {
+	labels := make([]string, len(p.Labels))
+	for i, l := range p.Labels {
+		labels[i] = l.Name
+	}
+	return issueRecord(p.Number, p.Title, p.User.Login, p.Assignee.Login, p.Milestone.Title, p.State, labels, p.Comments, votes, p.CreatedAt, p.UpdatedAt, p.HTMLURL)
+}
+
+func issueRecords(v interface{}, votes bool) []Record {
+	records := []Record{}
+	switch issues := v.(type) {
+	case []*Issue:
+		for _, p := range issues {
+			var v interface{}
+			if votes {
+				v = p.Votes
+			}
+			records = append(records, ghIssueRecord(p.Issue, v))
+		}
+	case []*gh.Issue:
+		for _, p := range issues {
+			records = append(records, ghIssueRecord(p, nil))
+		}
+	case []*gh.SearchItem:
+		for _, p := range issues {
+			labels := make([]string, len(p.Labels))
+			for i, l := range p.Labels {
+				labels[i] = l.Name
+			}
+			records = append(records, issueRecord(p.Number, p.Title, p.User.Login, p.Assignee.Login, p.Milestone.Title, p.State, labels, p.Comments, nil, p.CreatedAt, p.UpdatedAt, p.HTMLURL))
+		}
+	}
+	return records
+}
+
+func reviewerRecords(reviewers map[string][]string) []Record {
+	files := make([]string, 0, len(reviewers))
+	for file := range reviewers {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	records := []Record{}
+	for _, file := range files {
+		fileReviewers := append([]string{}, reviewers[file]...)
+		sort.Strings(fileReviewers)
+		records = append(records, Record{
+			{"file", file},
+			{"reviewers", fileReviewers},
+		})
+	}
+	return records
+}
+
+func ownershipRecords(node *OwnershipTree, records []Record) []Record {
+	records = append(records, Record{
+		{"path", node.Path},
+		{"maintainers", nonNil(node.Maintainers)},
+		{"inherited", node.Inherited},
+	})
+	for _, child := range node.Children {
+		records = ownershipRecords(child, records)
+	}
+	return records
+}
+
+// maintainedFiles returns the files of the pull request `maintainer` reviews
+func maintainedFiles(p *PullRequest, maintainer string) []string {
+	files := []string{}
+	for file, fileReviewers := range p.Reviewers {
+		for _, r := range fileReviewers {
+			if r == maintainer {
+				files = append(files, file)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func maintainerRecord(maintainer string, paths []string, pulls []*PullRequest) Record {
+	prs := []Record{}
+	for _, p := range pulls {
+		prs = append(prs, Record{
+			{"number", p.Number},
+			{"title", p.Title},
+			{"user", p.User.Login},
+			{"updated_at", timeValue(p.UpdatedAt)},
+			{"files", maintainedFiles(p, maintainer)},
+		})
+	}
+	return Record{
+		{"maintainer", maintainer},
+		{"paths", nonNil(paths)},
+		{"pull_requests", prs},
+	}
+}
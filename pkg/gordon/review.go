@@ -7,8 +7,10 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
+	gh "github.com/crosbymichael/octokat"
 	"github.com/fkautz/codereview/patch"
 )
 
@@ -101,12 +103,7 @@ func ReviewPatch(input []byte, maintainers map[string][]string) (map[string][]st
 				continue
 			}
 
-			var fileMaintainers map[string]bool
-			fileMaintainers = index[target]
-			for len(fileMaintainers) == 0 {
-				target = path.Dir(target)
-				fileMaintainers = index[target]
-			}
+			_, fileMaintainers := findOwner(index, target)
 			reviewers[originalTarget] = mapReviewers(fileMaintainers)
 		}
 	}
@@ -125,16 +122,26 @@ type Maintainer struct {
 	Raw      string
 }
 
-// Currently not being used
+// LoadMaintainerFile parses the MAINTAINERS file of `dir`, indexing the maintainers by target
 func LoadMaintainerFile(dir string) (MaintainerFile, error) {
-	src, err := os.Open(path.Join(dir, "MAINTAINERS"))
+	src, err := os.Open(path.Join(dir, MaintainerFileName))
 	if err != nil {
 		return nil, err
 	}
+	defer src.Close()
+
 	maintainers := make(MaintainerFile)
 	scanner := bufio.NewScanner(src)
 	for scanner.Scan() {
-		m := parseMaintainer(scanner.Text())
+		t := scanner.Text()
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		m := parseMaintainer(t)
+		if t[0] == '#' && m.Email == "" {
+			// plain comment
+			continue
+		}
 		if m.Username == "" && m.Email == "" && m.FullName == "" {
 			return nil, fmt.Errorf("Incorrect maintainer format: %s", m.Raw)
 		}
@@ -156,6 +163,9 @@ func parseMaintainer(line string) *Maintainer {
 	)
 	re := regexp.MustCompile("^[ \t]*(#|)((?P<target>[^: ]*) *:|) *(?P<fullname>[a-zA-Z][^<]*) *<(?P<email>[^>]*)> *(\\(@(?P<username>[^\\)]+)\\)|).*$")
 	match := re.FindStringSubmatch(line)
+	if match == nil {
+		return &Maintainer{Raw: line}
+	}
 	return &Maintainer{
 		Active:   match[commentIndex] == "",
 		Target:   path.Base(path.Clean(match[targetIndex])),
@@ -166,12 +176,11 @@ func parseMaintainer(line string) *Maintainer {
 	}
 }
 
-// Currently not being used
-//
 // TopMostMaintainerFile moves up the directory tree looking for a MAINTAINERS file,
 // parses the top-most file it finds, and returns its contents.
 // This is used to find the top-level maintainer of a project for certain
 // privileged reviews, such as authorizing changes to a MAINTAINERS file.
+// The maintainers it returns are marked as Lead.
 func TopMostMaintainerFile(dir string) (MaintainerFile, error) {
 	if _, err := os.Stat(dir); err != nil {
 		return nil, err
@@ -198,5 +207,84 @@ func TopMostMaintainerFile(dir string) (MaintainerFile, error) {
 	} else if err != nil {
 		return nil, err
 	}
+	for _, maintainers := range current {
+		for _, m := range maintainers {
+			m.Lead = true
+		}
+	}
 	return current, nil
 }
+
+// ReviewerLogins flattens the result of GetReviewersForPR into a sorted list of
+// unique github usernames. Email addresses and the logins in `exclude`, usually
+// the author of the pull request, are left out.
+func ReviewerLogins(reviewers map[string][]string, exclude ...string) []string {
+	var (
+		logins []string
+		seen   = make(map[string]bool)
+	)
+	for _, e := range exclude {
+		seen[e] = true
+	}
+	for _, fileReviewers := range reviewers {
+		for _, r := range fileReviewers {
+			if r == "" || strings.Contains(r, "@") || seen[r] {
+				continue
+			}
+			seen[r] = true
+			logins = append(logins, r)
+		}
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+// PingMarker is hidden in the comments posted by `pulls reviewers --ping` so that
+// the next run knows who has already been pinged.
+const PingMarker = "<!-- gordon:ping -->"
+
+var mentionRegexp = regexp.MustCompile(`@([a-zA-Z0-9][a-zA-Z0-9-]*)`)
+
+// GetPingedReviewers returns the logins mentioned in the ping comments
+// previously left on a pull request.
+func GetPingedReviewers(comments []gh.Comment) []string {
+	var (
+		pinged []string
+		seen   = make(map[string]bool)
+	)
+	for _, c := range comments {
+		if !strings.Contains(c.Body, PingMarker) {
+			continue
+		}
+		for _, match := range mentionRegexp.FindAllStringSubmatch(c.Body, -1) {
+			if login := match[1]; !seen[login] {
+				seen[login] = true
+				pinged = append(pinged, login)
+			}
+		}
+	}
+	sort.Strings(pinged)
+	return pinged
+}
+
+// PatchFiles returns the unique paths of the files affected by a git-formatted patch
+func PatchFiles(src []byte) ([]string, error) {
+	set, err := patch.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		files []string
+		seen  = make(map[string]bool)
+	)
+	for _, f := range set.File {
+		for _, name := range []string{f.Dst, f.Src} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
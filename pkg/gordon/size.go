@@ -0,0 +1,74 @@
+package gordon
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Sizes are the largest number of changed lines, additions and deletions, of
+// each size of pull request, anything bigger is XL. They can be set in the
+// "sizes" section of the repository configuration, e.g.
+//
+//	{
+//		"sizes": {"xs": 10, "s": 50, "m": 250, "l": 1000}
+//	}
+type Sizes struct {
+	XS int `json:"xs"`
+	S  int `json:"s"`
+	M  int `json:"m"`
+	L  int `json:"l"`
+}
+
+// DefaultSizes are used by repositories that don't configure their own
+var DefaultSizes = Sizes{XS: 10, S: 100, M: 500, L: 1000}
+
+// UnmarshalJSON keeps the DefaultSizes the configuration leaves out
+func (s *Sizes) UnmarshalJSON(data []byte) error {
+	// the alias doesn't have the method, not to recurse
+	type sizes Sizes
+	v := sizes(DefaultSizes)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Sizes(v)
+	return nil
+}
+
+// Validate checks that each size is larger than the previous one
+func (s Sizes) Validate() error {
+	if s.XS < 0 || s.XS >= s.S || s.S >= s.M || s.M >= s.L {
+		return fmt.Errorf("invalid sizes %d, %d, %d and %d: xs, s, m and l must be increasing", s.XS, s.S, s.M, s.L)
+	}
+	return nil
+}
+
+// Label returns the size, XS to XL, of a change of `lines` lines
+func (s Sizes) Label(lines int) string {
+	switch {
+	case lines <= s.XS:
+		return "XS"
+	case lines <= s.S:
+		return "S"
+	case lines <= s.M:
+		return "M"
+	case lines <= s.L:
+		return "L"
+	}
+	return "XL"
+}
+
+// LoadSizes returns the sizes configured for the current repository, or the
+// DefaultSizes along with an error when they are invalid
+func LoadSizes() (Sizes, error) {
+	config, err := LoadRepoConfig()
+	if err != nil {
+		return DefaultSizes, err
+	}
+	if config.Sizes == nil {
+		return DefaultSizes, nil
+	}
+	if err := config.Sizes.Validate(); err != nil {
+		return DefaultSizes, fmt.Errorf("%s: %v", RepoConfigFileName, err)
+	}
+	return *config.Sizes, nil
+}
@@ -0,0 +1,38 @@
+package gordon
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSizesUnmarshalKeepsDefaults(t *testing.T) {
+	var config RepoConfig
+	if err := json.Unmarshal([]byte(`{"sizes": {"l": 2000}}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultSizes
+	expected.L = 2000
+	if *config.Sizes != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *config.Sizes)
+	}
+	if label := config.Sizes.Label(5); label != "XS" {
+		t.Errorf("expected a 5 lines change to be XS, got %s", label)
+	}
+}
+
+func TestSizesValidate(t *testing.T) {
+	for _, test := range []struct {
+		sizes Sizes
+		valid bool
+	}{
+		{DefaultSizes, true},
+		{Sizes{XS: 0, S: 1, M: 2, L: 3}, true},
+		{Sizes{XS: 10, S: 10, M: 500, L: 1000}, false},
+		{Sizes{XS: 10, S: 100, M: 50, L: 1000}, false},
+		{Sizes{XS: -1, S: 100, M: 500, L: 1000}, false},
+	} {
+		if err := test.sizes.Validate(); (err == nil) != test.valid {
+			t.Errorf("%+v: expected valid to be %t, got %v", test.sizes, test.valid, err)
+		}
+	}
+}
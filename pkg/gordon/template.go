@@ -0,0 +1,128 @@
+package gordon
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+)
+
+// FormatTemplate is the format of the listings given as a Go template
+const FormatTemplate = "template"
+
+// formatTemplate is executed for each item listed, see SetTemplate
+var formatTemplate *template.Template
+
+// TemplateFuncs are the functions the templates can call on top of the
+// builtin ones. The value comes last so that they can be piped:
+// {{.Title | truncate 40}}, {{.Labels | join ","}}, {{.UpdatedAt | ago}}.
+var TemplateFuncs = template.FuncMap{
+	"ago":      ago,
+	"color":    color,
+	"truncate": truncateTo,
+	"join":     join,
+}
+
+// ParseTemplate parses a template with the TemplateFuncs
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("format").Funcs(TemplateFuncs).Parse(text)
+}
+
+// SetTemplate selects a Go template as the output format. Like the other
+// machine formats, it turns off the progress dots, the colors are left to the
+// color function.
+func SetTemplate(text string) error {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return err
+	}
+	Format, formatTemplate, Quiet = FormatTemplate, t, true
+	return nil
+}
+
+// WriteTemplate executes the template for `v`, or for each of its items when
+// it is a slice, each output followed by a newline
+func WriteTemplate(w io.Writer, t *template.Template, v interface{}) error {
+	items := reflect.ValueOf(v)
+	if items.Kind() != reflect.Slice {
+		items = reflect.ValueOf([]interface{}{v})
+	}
+	for i := 0; i < items.Len(); i++ {
+		if err := t.Execute(w, items.Index(i).Interface()); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ago is how long ago a date was, e.g. "3 days"
+func ago(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return HumanDuration(time.Since(t)), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return HumanDuration(time.Since(*t)), nil
+	}
+	return "", fmt.Errorf("ago expects a date, got %T", v)
+}
+
+// color colors `s` when the output is a terminal
+func color(name string, s interface{}) (string, error) {
+	return colorFunc(Colorize)(name, s)
+}
+
+// colorFunc returns a color function coloring only when `on`
+func colorFunc(on bool) func(string, interface{}) (string, error) {
+	return func(name string, s interface{}) (string, error) {
+		name = strings.ToLower(name)
+		if _, exists := brushes[name]; !exists {
+			return "", fmt.Errorf("unknown color %q, expected green, red, darkred, yellow or darkyellow", name)
+		}
+		return paint(on, name, fmt.Sprint(s)), nil
+	}
+}
+
+// truncateTo cuts `s` to `n` characters, ending it with "..." when it was
+// longer
+func truncateTo(n int, s interface{}) string {
+	str := fmt.Sprint(s)
+	if runes := []rune(str); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return str
+}
+
+// join joins strings, or the names of labels, with `sep`
+func join(sep string, v interface{}) (string, error) {
+	switch list := v.(type) {
+	case []string:
+		return strings.Join(list, sep), nil
+	case []gh.Label:
+		return strings.Join(labelNames(list), sep), nil
+	case []*gh.Label:
+		names := make([]string, len(list))
+		for i, l := range list {
+			names[i] = l.Name
+		}
+		return strings.Join(names, sep), nil
+	}
+	items := reflect.ValueOf(v)
+	if items.Kind() != reflect.Slice {
+		return "", fmt.Errorf("join expects a list, got %T", v)
+	}
+	out := make([]string, items.Len())
+	for i := range out {
+		out[i] = fmt.Sprint(items.Index(i).Interface())
+	}
+	return strings.Join(out, sep), nil
+}
@@ -0,0 +1,39 @@
+package gordon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplateEscapes(t *testing.T) {
+	defer func(format string, quiet, colorize bool) {
+		Format, Quiet, Colorize, formatTemplate = format, quiet, colorize, nil
+	}(Format, Quiet, Colorize)
+
+	item := struct {
+		Number int
+		Title  string
+	}{42, "Fix it"}
+	for _, test := range []struct {
+		set      func(string) error
+		text     string
+		expected string
+	}{
+		// typed in a shell, --format unescapes tabs and newlines
+		{SetFormat, `{{.Number}}\t{{.Title}}\n`, "42\tFix it\n\n"},
+		// a --template-file is taken as it is
+		{SetTemplate, `{{.Number}}\t{{.Title}}`, "42\\tFix it\n"},
+		{SetTemplate, "{{.Number}}\t{{printf \"%q\" \"a\\nb\"}}", "42\t\"a\\nb\"\n"},
+	} {
+		if err := test.set(test.text); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WriteTemplate(&buf, formatTemplate, item); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.text, test.expected, buf.String())
+		}
+	}
+}
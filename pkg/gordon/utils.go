@@ -7,7 +7,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -26,6 +28,23 @@ func PrintVerboseCommand(cmd *exec.Cmd) {
 	}
 }
 
+// Quiet turns off the progress dots, for the output to be parsed
+var Quiet = false
+
+// Progress prints a dot while the pull requests or issues are fetched
+func Progress() {
+	if !Quiet {
+		fmt.Printf(".")
+	}
+}
+
+// ClearProgress erases the line of dots printed by Progress
+func ClearProgress() {
+	if !Quiet {
+		fmt.Printf("%c[2K\r", 27)
+	}
+}
+
 func Fatalf(format string, args ...interface{}) {
 	if !strings.HasSuffix(format, "\n") {
 		format = format + "\n"
@@ -132,3 +151,95 @@ func GetTopLevelGitRepo() (string, error) {
 	}
 	return strings.Trim(string(output), "\n"), nil
 }
+
+// GetUpstreamBase returns the branch of `remote` that pull requests are based
+// on, as pointed to by its HEAD, e.g. "origin/master"
+func GetUpstreamBase(remote string) string {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", remote+"/HEAD")
+	PrintVerboseCommand(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return remote + "/master"
+	}
+	return strings.Trim(string(output), "\n")
+}
+
+// GetDiffForRange returns the patch of a git revision range such as "master...HEAD"
+func GetDiffForRange(revRange string) ([]byte, error) {
+	// the configuration of the user mustn't change the format of the patch
+	cmd := exec.Command("git", "diff", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", revRange)
+	PrintVerboseCommand(cmd)
+	cmd.Stderr = os.Stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %v", revRange, err)
+	}
+	return output, nil
+}
+
+// ParseDuration parses a duration such as "14d", "2w" or "36h". On top of the
+// units understood by time.ParseDuration it accepts days (d) and weeks (w),
+// and a number without unit is a number of days.
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	for unit, d := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, unit) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, unit), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(n * float64(d)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// ParseTime parses a date, as 2006-01-02 or in RFC 3339 format, or a duration
+// as accepted by ParseDuration counted back from `now`, e.g. "14d" for two
+// weeks ago.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	d, err := ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected 2006-01-02 or a duration such as 14d", s)
+	}
+	return now.Add(-d), nil
+}
+
+// ParseTimeFlags parses the flags holding dates, as accepted by ParseTime, and
+// durations into the values they are mapped to. `flag` returns the value of a
+// flag by name, empty when it isn't set.
+func ParseTimeFlags(flag func(name string) string, times map[string]*time.Time, durations map[string]*time.Duration) error {
+	var err error
+	now := time.Now()
+	for name, t := range times {
+		if value := flag(name); value != "" {
+			if *t, err = ParseTime(value, now); err != nil {
+				return fmt.Errorf("--%s: %v", name, err)
+			}
+		}
+	}
+	for name, d := range durations {
+		if value := flag(name); value != "" {
+			if *d, err = ParseDuration(value); err != nil {
+				return fmt.Errorf("--%s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package gordon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeFlags(t *testing.T) {
+	flags := map[string]string{"created-after": "2020-01-02", "older-than": "2w"}
+	var (
+		created, updated time.Time
+		older, idle      time.Duration
+	)
+	err := ParseTimeFlags(func(name string) string { return flags[name] },
+		map[string]*time.Time{"created-after": &created, "updated-before": &updated},
+		map[string]*time.Duration{"older-than": &older, "idle-since-author": &idle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Format("2006-01-02") != "2020-01-02" || !updated.IsZero() {
+		t.Errorf("unexpected dates %s and %s", created, updated)
+	}
+	if older != 14*24*time.Hour || idle != 0 {
+		t.Errorf("unexpected durations %s and %s", older, idle)
+	}
+
+	flags["older-than"] = "soon"
+	err = ParseTimeFlags(func(name string) string { return flags[name] }, nil, map[string]*time.Duration{"older-than": &older})
+	if err == nil || err.Error() != `--older-than: invalid duration "soon"` {
+		t.Errorf("expected the flag to be named in the error, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package gordon
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Workload sums up what's waiting on a maintainer in the open pull requests
+type Workload struct {
+	Maintainer string
+	// Touching are the pull requests touching the paths of the maintainer
+	Touching []*PullRequest
+	// Assigned are the pull requests assigned to the maintainer
+	Assigned []*PullRequest
+	// Pinged are the pull requests where the maintainer was mentioned
+	// and did not comment or review since
+	Pinged []*PullRequest
+	// OldestPing is the pinged pull request waiting the longest, pinged at OldestPingAt
+	OldestPing   *PullRequest
+	OldestPingAt time.Time
+}
+
+// GetWorkloads computes the workload of every maintainer with a github username
+// in `maintainers`. The pull requests need their comments and reviews, as
+// fetched by GetFullPullRequests, and their reviewers, as loaded by LoadReviewers.
+// The result is sorted by the number of pull requests touching the paths of
+// each maintainer, busiest first.
+func GetWorkloads(prs []*PullRequest, maintainers map[string][]string) []*Workload {
+	var workloads []*Workload
+	for maintainer := range maintainers {
+		if maintainer == "" || strings.Contains(maintainer, "@") {
+			continue
+		}
+		w := &Workload{Maintainer: maintainer}
+		for _, p := range prs {
+			if p.ReviewedBy(maintainer) {
+				w.Touching = append(w.Touching, p)
+			}
+			if p.Assignee != nil && p.Assignee.Login == maintainer {
+				w.Assigned = append(w.Assigned, p)
+			}
+			if at, pinged := PingedAt(p, maintainer); pinged {
+				w.Pinged = append(w.Pinged, p)
+				if w.OldestPing == nil || at.Before(w.OldestPingAt) {
+					w.OldestPing, w.OldestPingAt = p, at
+				}
+			}
+		}
+		workloads = append(workloads, w)
+	}
+	sort.Sort(byWorkload(workloads))
+	return workloads
+}
+
+// PingedAt returns when `login` was first mentioned by someone else in the
+// description or the comments of a pull request without commenting or
+// reviewing since.
+func PingedAt(p *PullRequest, login string) (time.Time, bool) {
+	type activity struct {
+		author string
+		body   string
+		at     time.Time
+	}
+	events := []activity{{author: p.User.Login, body: p.Body, at: p.CreatedAt}}
+	for _, c := range p.CommentsBody {
+		events = append(events, activity{author: c.User.Login, body: c.Body, at: c.CreatedAt})
+	}
+	for _, r := range p.Reviews {
+		events = append(events, activity{author: r.User.Login, body: r.Body, at: r.SubmittedAt})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+
+	var (
+		pingedAt time.Time
+		pinged   bool
+	)
+	for _, e := range events {
+		if strings.EqualFold(e.author, login) {
+			pinged = false
+			continue
+		}
+		if !pinged && isMentioned(e.body, login) {
+			pingedAt, pinged = e.at, true
+		}
+	}
+	return pingedAt, pinged
+}
+
+func isMentioned(body, login string) bool {
+	for _, match := range mentionRegexp.FindAllStringSubmatch(body, -1) {
+		if strings.EqualFold(match[1], login) {
+			return true
+		}
+	}
+	return false
+}
+
+type byWorkload []*Workload
+
+func (a byWorkload) Len() int      { return len(a) }
+func (a byWorkload) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byWorkload) Less(i, j int) bool {
+	if len(a[i].Touching) != len(a[j].Touching) {
+		return len(a[i].Touching) > len(a[j].Touching)
+	}
+	return a[i].Maintainer < a[j].Maintainer
+}
@@ -0,0 +1,527 @@
+// Package tui is a full-screen interface to review pull requests: the list
+// of the pull requests, with the details of the one selected, its diff and
+// its comments, and keys to act on it.
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+type view int
+
+const (
+	listView view = iota
+	diffView
+	commentsView
+)
+
+// Help lists the keys of the interface
+const Help = "j/k move  enter details  D diff  C comments  a approve  c comment  t take  d drop  o checkout  l label  m merge  r refresh  q quit"
+
+// prompt reads a line on the status line, `submit` gets it on enter
+type prompt struct {
+	label  string
+	input  []rune
+	submit func(string)
+}
+
+// App is the state of the interface
+type App struct {
+	screen  Screen
+	backend Backend
+	options gordon.RenderOptions
+
+	prs      []*gordon.PullRequest
+	selected int
+	// top is the first pull request listed, the list scrolls to keep the
+	// selected one in sight
+	top     int
+	details map[int]*Details
+
+	view view
+	// paneFocus is true when the keys scroll the detail pane rather than
+	// move in the list
+	paneFocus bool
+	// scroll is the first line shown in the detail pane, the diff and the
+	// comments
+	scroll int
+
+	status string
+	prompt *prompt
+	quit   bool
+}
+
+// New returns the interface drawing on `screen`, the tables are rendered with
+// `options`
+func New(screen Screen, backend Backend, options gordon.RenderOptions) *App {
+	// the screen is drawn as text, without colors
+	options.Format, options.Color = gordon.FormatTable, false
+	return &App{
+		screen:  screen,
+		backend: backend,
+		options: options,
+		details: make(map[int]*Details),
+	}
+}
+
+// Run lists the pull requests, then handles the keys until q is pressed or
+// the screen runs out of keys
+func (a *App) Run() error {
+	if err := a.refresh(); err != nil {
+		return err
+	}
+	for !a.quit {
+		if err := a.draw(); err != nil {
+			return err
+		}
+		key, err := a.screen.ReadKey()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		a.handle(key)
+	}
+	return nil
+}
+
+// current returns the selected pull request, nil when there are none
+func (a *App) current() *gordon.PullRequest {
+	if a.selected >= len(a.prs) {
+		return nil
+	}
+	return a.prs[a.selected]
+}
+
+// refresh lists the pull requests again, keeping the selected one
+func (a *App) refresh() error {
+	var number int
+	if p := a.current(); p != nil {
+		number = p.Number
+	}
+	a.busy("Loading the pull requests...")
+	prs, err := a.backend.PullRequests()
+	if err != nil {
+		return err
+	}
+	a.prs, a.selected = prs, 0
+	for i, p := range prs {
+		if p.Number == number {
+			a.selected = i
+		}
+	}
+	a.details, a.paneFocus, a.scroll = make(map[int]*Details), false, 0
+	a.status = fmt.Sprintf("%d pull requests", len(prs))
+	return nil
+}
+
+// load gets the details of the selected pull request
+func (a *App) load() (*Details, error) {
+	p := a.current()
+	if p == nil {
+		return nil, fmt.Errorf("no pull request selected")
+	}
+	if d, exists := a.details[p.Number]; exists {
+		return d, nil
+	}
+	a.busy(fmt.Sprintf("Loading #%d...", p.Number))
+	d, err := a.backend.Details(p)
+	if err != nil {
+		return nil, err
+	}
+	a.details[p.Number] = d
+	a.status = ""
+	return d, nil
+}
+
+// busy tells what is being waited for
+func (a *App) busy(status string) {
+	a.status = status
+	a.draw()
+}
+
+func (a *App) fail(err error) {
+	a.status = "Error: " + strings.Replace(err.Error(), "\n", " ", -1)
+}
+
+func (a *App) handle(key Key) {
+	if a.prompt != nil {
+		a.handlePrompt(key)
+		return
+	}
+	switch key {
+	case KeyCtrlC:
+		a.quit = true
+	case "q", KeyEsc:
+		switch {
+		case a.view != listView:
+			a.view, a.scroll = listView, 0
+		case a.paneFocus:
+			a.paneFocus = false
+		case key == "q":
+			a.quit = true
+		}
+	case "j", KeyDown:
+		a.move(1)
+	case "k", KeyUp:
+		a.move(-1)
+	case KeyPageDown, " ":
+		a.move(a.page())
+	case KeyPageUp:
+		a.move(-a.page())
+	case "g", KeyHome:
+		a.move(-len(a.prs) - a.scroll)
+	case "G", KeyEnd:
+		a.move(len(a.prs) + len(a.pageLines()))
+	case KeyEnter, KeyTab:
+		if a.view == listView {
+			if _, err := a.load(); err != nil {
+				a.fail(err)
+				return
+			}
+			a.paneFocus, a.scroll = !a.paneFocus, 0
+		}
+	case "D":
+		a.open(diffView)
+	case "C":
+		a.open(commentsView)
+	case "r":
+		if err := a.refresh(); err != nil {
+			a.fail(err)
+		}
+	default:
+		a.action(key)
+	}
+}
+
+// move moves the selection in the list, or scrolls the detail pane and the
+// other views
+func (a *App) move(n int) {
+	if a.view == listView && !a.paneFocus {
+		a.selected += n
+		if a.selected >= len(a.prs) {
+			a.selected = len(a.prs) - 1
+		}
+		if a.selected < 0 {
+			a.selected = 0
+		}
+		return
+	}
+	a.scroll += n
+	if last := len(a.pageLines()) - a.page(); a.scroll > last {
+		a.scroll = last
+	}
+	if a.scroll < 0 {
+		a.scroll = 0
+	}
+}
+
+func (a *App) open(v view) {
+	if _, err := a.load(); err != nil {
+		a.fail(err)
+		return
+	}
+	a.view, a.scroll = v, 0
+}
+
+// action runs the key bound to an action on the selected pull request
+func (a *App) action(key Key) {
+	p := a.current()
+	if p == nil {
+		return
+	}
+	number := strconv.Itoa(p.Number)
+	switch key {
+	case "a":
+		a.busy(fmt.Sprintf("Approving #%s...", number))
+		if err := a.backend.Approve(number); err != nil {
+			a.fail(err)
+			return
+		}
+		a.changed(p)
+		a.status = fmt.Sprintf("#%s approved", number)
+	case "c":
+		a.ask(fmt.Sprintf("Comment on #%s: ", number), func(body string) {
+			if body == "" {
+				return
+			}
+			a.busy(fmt.Sprintf("Commenting on #%s...", number))
+			if err := a.backend.Comment(number, body); err != nil {
+				a.fail(err)
+				return
+			}
+			a.changed(p)
+			a.status = fmt.Sprintf("Commented on #%s", number)
+		})
+	case "t":
+		a.busy(fmt.Sprintf("Taking #%s...", number))
+		login, assigned, err := a.backend.Take(number)
+		if err != nil {
+			a.fail(err)
+			return
+		}
+		a.relist()
+		if assigned {
+			a.status = fmt.Sprintf("Assigned #%s to %s", number, login)
+		} else {
+			a.status = fmt.Sprintf("No permission to assign, %s was added as #volunteer on #%s", login, number)
+		}
+	case "d":
+		a.busy(fmt.Sprintf("Dropping #%s...", number))
+		if err := a.backend.Drop(number); err != nil {
+			a.fail(err)
+			return
+		}
+		a.relist()
+		a.status = fmt.Sprintf("Unassigned #%s", number)
+	case "o":
+		if err := a.checkout(p); err != nil {
+			a.fail(err)
+			return
+		}
+		a.status = fmt.Sprintf("Checked out #%s", number)
+	case "l":
+		a.ask(fmt.Sprintf("Labels of #%s (name to add, -name to remove): ", number), func(input string) {
+			var add, remove []string
+			for _, name := range strings.Fields(input) {
+				if strings.HasPrefix(name, "-") {
+					remove = append(remove, name[1:])
+				} else {
+					add = append(add, strings.TrimPrefix(name, "+"))
+				}
+			}
+			if len(add) == 0 && len(remove) == 0 {
+				return
+			}
+			a.busy(fmt.Sprintf("Labeling #%s...", number))
+			labels, err := a.backend.Label(number, add, remove)
+			if err != nil {
+				a.fail(err)
+				return
+			}
+			p.Labels = labels
+			a.changed(p)
+			names := make([]string, len(labels))
+			for i, l := range labels {
+				names[i] = l.Name
+			}
+			a.status = fmt.Sprintf("#%s is labeled %s", number, strings.Join(names, ", "))
+		})
+	case "m":
+		a.ask(fmt.Sprintf("Merge #%s? (y/n) ", number), func(answer string) {
+			if answer != "y" && answer != "yes" {
+				return
+			}
+			a.busy(fmt.Sprintf("Merging #%s...", number))
+			message, err := a.backend.Merge(number)
+			if err != nil {
+				a.fail(err)
+				return
+			}
+			a.relist()
+			a.status = message
+		})
+	}
+}
+
+// checkout gives the terminal to git while it checks the pull request out
+func (a *App) checkout(p *gordon.PullRequest) error {
+	if err := a.screen.Suspend(); err != nil {
+		return err
+	}
+	err := a.backend.Checkout(p)
+	if resumeErr := a.screen.Resume(); err == nil {
+		err = resumeErr
+	}
+	return err
+}
+
+// changed forgets the details of `p`, reloading them if they are shown
+func (a *App) changed(p *gordon.PullRequest) {
+	if _, shown := a.details[p.Number]; !shown {
+		return
+	}
+	delete(a.details, p.Number)
+	if _, err := a.load(); err != nil {
+		a.fail(err)
+	}
+}
+
+// relist lists the pull requests again after an action changing them
+func (a *App) relist() {
+	if err := a.refresh(); err != nil {
+		a.fail(err)
+	}
+}
+
+func (a *App) ask(label string, submit func(string)) {
+	a.prompt = &prompt{label: label, submit: submit}
+}
+
+func (a *App) handlePrompt(key Key) {
+	p := a.prompt
+	switch key {
+	case KeyEnter:
+		a.prompt, a.status = nil, ""
+		p.submit(strings.TrimSpace(string(p.input)))
+	case KeyEsc, KeyCtrlC:
+		a.prompt = nil
+	case KeyBackspace:
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+	default:
+		if r, ok := key.Rune(); ok {
+			p.input = append(p.input, r)
+		}
+	}
+}
+
+// The screen is the title, the body, the status line and the help line
+func (a *App) bodyHeight() int {
+	_, height := a.screen.Size()
+	if height < 4 {
+		return 1
+	}
+	return height - 3
+}
+
+// listHeight is the number of lines of the list above the detail pane
+func (a *App) listHeight() int {
+	return (a.bodyHeight() + 1) / 2
+}
+
+// page is the number of lines scrolled through at once
+func (a *App) page() int {
+	if a.view == listView {
+		if !a.paneFocus {
+			return a.listHeight() - 1
+		}
+		return a.bodyHeight() - a.listHeight() - 1
+	}
+	return a.bodyHeight()
+}
+
+// pageLines are the lines of what scrolls: the detail pane, the diff or the
+// comments of the selected pull request
+func (a *App) pageLines() []string {
+	p := a.current()
+	if p == nil {
+		return nil
+	}
+	d, exists := a.details[p.Number]
+	if !exists {
+		return []string{fmt.Sprintf("Press enter to load the details of #%d", p.Number)}
+	}
+	var buf bytes.Buffer
+	r := gordon.NewRenderer(&buf, a.options)
+	switch a.view {
+	case diffView:
+		r.Patch(bytes.NewReader(d.Diff))
+	case commentsView:
+		r.Comments(d.Comments)
+	default:
+		r.PullRequest(d.PullRequest, d.Status, d.Approval)
+		if len(d.Reviewers) > 0 {
+			fmt.Fprintln(&buf, "Reviewers:")
+			r.Reviewers(d.Reviewers)
+		}
+	}
+	return splitLines(buf.String())
+}
+
+func (a *App) draw() error {
+	width, height := a.screen.Size()
+	lines := []string{a.title()}
+	if a.view == listView {
+		lines = append(lines, a.list()...)
+		lines = append(lines, strings.Repeat("-", width))
+		lines = append(lines, scrolled(a.pageLines(), a.scroll, a.bodyHeight()-a.listHeight()-1)...)
+	} else {
+		lines = append(lines, scrolled(a.pageLines(), a.scroll, a.bodyHeight())...)
+	}
+	if a.prompt != nil {
+		lines = append(lines, a.prompt.label+string(a.prompt.input)+"_")
+	} else {
+		lines = append(lines, a.status)
+	}
+	lines = append(lines, Help)
+
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	lines = lines[:height]
+	for i, l := range lines {
+		lines[i] = fit(l, width)
+	}
+	return a.screen.Draw(lines)
+}
+
+func (a *App) title() string {
+	p := a.current()
+	switch {
+	case p == nil:
+		return "No pull requests"
+	case a.view == diffView:
+		return fmt.Sprintf("Diff of #%d: %s", p.Number, p.Title)
+	case a.view == commentsView:
+		return fmt.Sprintf("Comments of #%d: %s", p.Number, p.Title)
+	}
+	return fmt.Sprintf("Pull requests %d/%d", a.selected+1, len(a.prs))
+}
+
+// list renders the table of the pull requests, scrolled to the selected one
+// which is marked with a ">"
+func (a *App) list() []string {
+	var buf bytes.Buffer
+	gordon.NewRenderer(&buf, a.options).PullRequests(a.prs)
+	table := splitLines(buf.String())
+	if len(table) == 0 {
+		return nil
+	}
+	rows := a.listHeight() - 1
+	if a.selected < a.top {
+		a.top = a.selected
+	}
+	if a.selected >= a.top+rows {
+		a.top = a.selected - rows + 1
+	}
+	lines := []string{"  " + table[0]}
+	for i := a.top; i < a.top+rows; i++ {
+		switch {
+		case i+1 >= len(table):
+			lines = append(lines, "")
+		case i == a.selected:
+			lines = append(lines, "> "+table[i+1])
+		default:
+			lines = append(lines, "  "+table[i+1])
+		}
+	}
+	return lines
+}
+
+// scrolled returns `height` lines of `lines` from `top`
+func scrolled(lines []string, top, height int) []string {
+	out := make([]string, height)
+	for i := range out {
+		if top+i < len(lines) {
+			out[i] = lines[top+i]
+		}
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+// stubBackend lists `count` pull requests, records the actions and fails
+// with `err` when set
+type stubBackend struct {
+	count   int
+	err     error
+	actions []string
+}
+
+func (b *stubBackend) PullRequests() ([]*gordon.PullRequest, error) {
+	var prs []*gordon.PullRequest
+	for i := 1; i <= b.count; i++ {
+		prs = append(prs, &gordon.PullRequest{PullRequest: &gh.PullRequest{
+			Number:    100 + i,
+			Title:     fmt.Sprintf("Change %d", i),
+			User:      gh.User{Login: "alice"},
+			Head:      gh.Commit{Sha: "0123456789abcdef"},
+			UpdatedAt: time.Now(),
+		}})
+	}
+	return prs, nil
+}
+
+func (b *stubBackend) Details(p *gordon.PullRequest) (*Details, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &Details{
+		PullRequest: p,
+		Comments:    []gh.Comment{{User: gh.User{Login: "bob"}, Body: "LGTM"}},
+		Diff:        []byte("diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"),
+	}, nil
+}
+
+func (b *stubBackend) act(action string, args ...interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.actions = append(b.actions, strings.TrimSpace(fmt.Sprintln(append([]interface{}{action}, args...)...)))
+	return nil
+}
+
+func (b *stubBackend) Approve(number string) error       { return b.act("approve", number) }
+func (b *stubBackend) Comment(number, body string) error { return b.act("comment", number, body) }
+func (b *stubBackend) Drop(number string) error          { return b.act("drop", number) }
+
+func (b *stubBackend) Take(number string) (string, bool, error) {
+	return "me", true, b.act("take", number)
+}
+
+func (b *stubBackend) Checkout(p *gordon.PullRequest) error {
+	return b.act("checkout", strconv.Itoa(p.Number))
+}
+
+func (b *stubBackend) Label(number string, add, remove []string) ([]gh.Label, error) {
+	return []gh.Label{{Name: "bug"}}, b.act("label", number, add, remove)
+}
+
+func (b *stubBackend) Merge(number string) (string, error) {
+	return "Pull Request successfully merged", b.act("merge", number)
+}
+
+// run plays `keys` on a new interface
+func run(t *testing.T, b *stubBackend, keys ...Key) *SimulationScreen {
+	screen := NewSimulationScreen(120, 20, keys...)
+	if err := New(screen, b, gordon.DefaultRenderOptions()).Run(); err != nil {
+		t.Fatal(err)
+	}
+	return screen
+}
+
+func contains(t *testing.T, screen *SimulationScreen, expected ...string) {
+	t.Helper()
+	for _, s := range expected {
+		if !strings.Contains(screen.String(), s) {
+			t.Errorf("expected %q on the screen:\n%s", s, screen)
+		}
+	}
+}
+
+func selected(screen *SimulationScreen) string {
+	for _, l := range screen.Lines() {
+		if strings.HasPrefix(l, "> ") {
+			return strings.Fields(l)[1]
+		}
+	}
+	return ""
+}
+
+func TestNavigation(t *testing.T) {
+	b := &stubBackend{count: 12}
+	for _, test := range []struct {
+		keys     []Key
+		selected string
+	}{
+		{nil, "101"},
+		{[]Key{"j", "j"}, "103"},
+		{[]Key{KeyDown, KeyUp, KeyDown}, "102"},
+		{[]Key{"k"}, "101"},
+		{[]Key{"G"}, "112"},
+		{[]Key{"G", "g"}, "101"},
+		// the list scrolls to keep the selection in sight
+		{[]Key{"j", "j", "j", "j", "j", "j", "j", "j", "j", "j"}, "111"},
+	} {
+		screen := run(t, b, test.keys...)
+		if s := selected(screen); s != test.selected {
+			t.Errorf("%v: expected #%s selected, got #%s:\n%s", test.keys, test.selected, s, screen)
+		}
+	}
+	if len(b.actions) > 0 {
+		t.Errorf("expected no actions, got %v", b.actions)
+	}
+}
+
+func TestViews(t *testing.T) {
+	b := &stubBackend{count: 3}
+	screen := run(t, b, "j")
+	contains(t, screen, "Pull requests 2/3", "Press enter to load the details of #102")
+
+	screen = run(t, b, "j", KeyEnter)
+	contains(t, screen, "Pull Request from: @alice", "No: 102")
+
+	screen = run(t, b, "D")
+	contains(t, screen, "Diff of #101", "-old", "+new")
+
+	screen = run(t, b, "C")
+	contains(t, screen, "Comments of #101", "@bob", "LGTM")
+
+	screen = run(t, b, "C", "q")
+	contains(t, screen, "Pull requests 1/3")
+}
+
+func TestQuit(t *testing.T) {
+	b := &stubBackend{count: 3}
+	screen := NewSimulationScreen(120, 20, "q", "j")
+	if err := New(screen, b, gordon.DefaultRenderOptions()).Run(); err != nil {
+		t.Fatal(err)
+	}
+	// "j" was left unread
+	if key, _ := screen.ReadKey(); key != "j" {
+		t.Errorf("expected q to quit, got %q pressed", key)
+	}
+}
+
+func TestActions(t *testing.T) {
+	b := &stubBackend{count: 3}
+	screen := NewSimulationScreen(120, 20, "j", "a", "c")
+	screen.Type("ship it!")
+	screen.Press(KeyBackspace, KeyEnter, "l")
+	screen.Type("bug -wip")
+	screen.Press(KeyEnter, "t", "d", "o")
+	if err := New(screen, b, gordon.DefaultRenderOptions()).Run(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"approve 102", "comment 102 ship it", "label 102 [bug] [wip]", "take 102", "drop 102", "checkout 102"}
+	if fmt.Sprint(b.actions) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, b.actions)
+	}
+	if screen.Suspended {
+		t.Errorf("expected the screen to be resumed after the checkout")
+	}
+	contains(t, screen, "Checked out #102")
+}
+
+func TestMergeConfirmation(t *testing.T) {
+	b := &stubBackend{count: 3}
+	screen := run(t, b, "m")
+	contains(t, screen, "Merge #101? (y/n) _")
+
+	for _, answer := range []string{"n", "", "maybe"} {
+		screen = NewSimulationScreen(120, 20, "m")
+		screen.Type(answer)
+		screen.Press(KeyEnter)
+		if err := New(screen, b, gordon.DefaultRenderOptions()).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// escape cancels too
+	run(t, b, "m", "y", KeyEsc)
+	if len(b.actions) > 0 {
+		t.Fatalf("expected no merge without a yes, got %v", b.actions)
+	}
+
+	screen = run(t, b, "m", "y", KeyEnter)
+	if fmt.Sprint(b.actions) != "[merge 101]" {
+		t.Errorf("expected #101 to be merged, got %v", b.actions)
+	}
+	contains(t, screen, "Pull Request successfully merged")
+}
+
+func TestErrors(t *testing.T) {
+	b := &stubBackend{count: 3, err: errors.New("API rate limit exceeded\nretry later")}
+	for _, keys := range [][]Key{
+		{"a"},
+		{KeyEnter},
+		{"D"},
+		{"t"},
+		{"m", "y", KeyEnter},
+	} {
+		screen := run(t, b, keys...)
+		contains(t, screen, "Error: API rate limit exceeded retry later")
+		// the list is still there
+		contains(t, screen, "> 101")
+	}
+
+	// without pull requests, the actions do nothing
+	screen := run(t, &stubBackend{}, "a", "j", KeyEnter)
+	contains(t, screen, "No pull requests", "Error: no pull request selected")
+}
@@ -0,0 +1,157 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	gh "github.com/crosbymichael/octokat"
+	"github.com/docker/gordon/pkg/filters"
+	"github.com/docker/gordon/pkg/gordon"
+)
+
+// Details are what the detail pane, the diff and the comments views show of
+// a pull request
+type Details struct {
+	PullRequest *gordon.PullRequest
+	Status      gh.CombinedStatus
+	// Approval and Reviewers are nil outside of a repository with
+	// MAINTAINERS files
+	Approval  *gordon.Approval
+	Reviewers map[string][]string
+	Comments  []gh.Comment
+	Diff      []byte
+}
+
+// Backend gets the pull requests and acts on them for the interface
+type Backend interface {
+	// PullRequests lists the pull requests to review
+	PullRequests() ([]*gordon.PullRequest, error)
+	Details(p *gordon.PullRequest) (*Details, error)
+
+	Approve(number string) error
+	Comment(number, body string) error
+	// Take returns the login of the user and whether they could be
+	// assigned rather than added as a volunteer
+	Take(number string) (login string, assigned bool, err error)
+	Drop(number string) error
+	Checkout(p *gordon.PullRequest) error
+	// Label adds and removes labels, returning the resulting ones
+	Label(number string, add, remove []string) ([]gh.Label, error)
+	// Merge returns the message of the merge, the merge policy applies
+	Merge(number string) (string, error)
+}
+
+// ManagerBackend lists the pull requests in a state matching a filter
+type ManagerBackend struct {
+	m       *gordon.MaintainerManager
+	state   string
+	options filters.PullRequestFilter
+	// details fetches each pull request for the size and ci columns
+	details bool
+}
+
+// NewManagerBackend returns the backend of the pull requests of `m` in
+// `state` that match `options`. With `details`, each of them is fetched for
+// the size and ci columns, two requests per pull request.
+func NewManagerBackend(m *gordon.MaintainerManager, state string, options filters.PullRequestFilter, details bool) *ManagerBackend {
+	return &ManagerBackend{m: m, state: state, options: options, details: details}
+}
+
+func (b *ManagerBackend) PullRequests() ([]*gordon.PullRequest, error) {
+	// a refresh shows the checks that were run again
+	b.m.ForgetStatuses()
+	prs, err := b.m.GetPullRequestsForBase(b.state, b.options.APISort(), b.options.APIBase())
+	if err != nil {
+		return nil, err
+	}
+	if prs, err = filters.FilterPullRequests(b.m, prs, b.options); err != nil {
+		return nil, err
+	}
+	if b.details {
+		// for the size and ci columns, what was fetched to filter isn't
+		// fetched again
+		prs = b.m.FetchPullRequests(prs, gordon.Need{Full: true, Status: true})
+	}
+	return prs, nil
+}
+
+func (b *ManagerBackend) Details(p *gordon.PullRequest) (*Details, error) {
+	number := strconv.Itoa(p.Number)
+	// fetch them again, they may have changed since the listing
+	full, err := b.m.GetFullPullRequest(number)
+	if err != nil {
+		return nil, err
+	}
+	fetched := b.m.FetchPullRequests([]*gordon.PullRequest{full}, gordon.Need{Comments: true, Diff: true, Status: true})
+	if len(fetched) == 0 {
+		return nil, fmt.Errorf("couldn't fetch the details of #%s", number)
+	}
+	full = fetched[0]
+	d := &Details{PullRequest: full, Comments: full.CommentsBody, Diff: full.Diff}
+	if full.Status != nil {
+		d.Status = *full.Status
+	}
+	if maintainers, err := b.m.Maintainers(); err == nil {
+		if d.Approval, err = full.LoadApproval(maintainers); err != nil {
+			return nil, err
+		}
+		b.m.LoadReviewers([]*gordon.PullRequest{full}, maintainers)
+		d.Reviewers = full.Reviewers
+	}
+	return d, nil
+}
+
+func (b *ManagerBackend) Approve(number string) error {
+	_, err := b.m.AddComment(number, "LGTM")
+	return err
+}
+
+func (b *ManagerBackend) Comment(number, body string) error {
+	_, err := b.m.AddComment(number, body)
+	return err
+}
+
+func (b *ManagerBackend) Take(number string) (string, bool, error) {
+	user, assigned, err := b.m.Take(number, false)
+	if err != nil {
+		return "", false, err
+	}
+	return user.Login, assigned, nil
+}
+
+func (b *ManagerBackend) Drop(number string) error {
+	return b.m.Drop(number)
+}
+
+func (b *ManagerBackend) Checkout(p *gordon.PullRequest) error {
+	return b.m.Checkout(p.PullRequest)
+}
+
+func (b *ManagerBackend) Label(number string, add, remove []string) ([]gh.Label, error) {
+	var (
+		labels []gh.Label
+		err    error
+	)
+	if len(add) > 0 {
+		if labels, err = b.m.ChangeLabels(number, gordon.LabelChange{Names: add}); err != nil {
+			return nil, err
+		}
+	}
+	if len(remove) > 0 {
+		if labels, err = b.m.ChangeLabels(number, gordon.LabelChange{Remove: true, Names: remove}); err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
+
+func (b *ManagerBackend) Merge(number string) (string, error) {
+	merge, err := b.m.MergePullRequest(number, "", false)
+	if err != nil {
+		return "", err
+	}
+	if !merge.Merged {
+		return "", fmt.Errorf("#%s wasn't merged: %s", number, merge.Message)
+	}
+	return merge.Message, nil
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Key is a key pressed, either the character typed or the name of a special
+// key such as KeyEnter
+type Key string
+
+// Special keys
+const (
+	KeyEnter     Key = "enter"
+	KeyEsc       Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyTab       Key = "tab"
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyLeft      Key = "left"
+	KeyRight     Key = "right"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyHome      Key = "home"
+	KeyEnd       Key = "end"
+	KeyCtrlC     Key = "ctrl-c"
+)
+
+// Rune returns the character typed, or false for a special key
+func (k Key) Rune() (rune, bool) {
+	if utf8.RuneCountInString(string(k)) != 1 {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(string(k))
+	return r, true
+}
+
+// Screen is where the interface is drawn and the keys are read from. The
+// terminal is one, a SimulationScreen stands in for it to script a session.
+type Screen interface {
+	// Size returns the number of columns and lines
+	Size() (width, height int)
+	// Draw replaces what is displayed with `lines`, they are expected to
+	// fit the screen
+	Draw(lines []string) error
+	// ReadKey waits for the next key, io.EOF ends the session
+	ReadKey() (Key, error)
+	// Suspend gives the screen back to the commands writing to the
+	// terminal, until Resume
+	Suspend() error
+	Resume() error
+	// Close restores the screen as it was
+	Close() error
+}
+
+// SimulationScreen is a screen of a fixed size that plays the keys it was
+// given and keeps everything drawn
+type SimulationScreen struct {
+	Width, Height int
+	// Frames are the successive contents of the screen
+	Frames    [][]string
+	Suspended bool
+
+	keys []Key
+}
+
+// NewSimulationScreen returns a screen pressing `keys` in order, then
+// returning io.EOF
+func NewSimulationScreen(width, height int, keys ...Key) *SimulationScreen {
+	return &SimulationScreen{Width: width, Height: height, keys: keys}
+}
+
+// Press queues more keys
+func (s *SimulationScreen) Press(keys ...Key) {
+	s.keys = append(s.keys, keys...)
+}
+
+// Type queues the keys typing `text`
+func (s *SimulationScreen) Type(text string) {
+	for _, r := range text {
+		s.keys = append(s.keys, Key(r))
+	}
+}
+
+// Lines returns what is displayed
+func (s *SimulationScreen) Lines() []string {
+	if len(s.Frames) == 0 {
+		return nil
+	}
+	return s.Frames[len(s.Frames)-1]
+}
+
+// String returns what is displayed, without the trailing spaces
+func (s *SimulationScreen) String() string {
+	lines := make([]string, len(s.Lines()))
+	for i, l := range s.Lines() {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *SimulationScreen) Size() (int, int) {
+	return s.Width, s.Height
+}
+
+func (s *SimulationScreen) Draw(lines []string) error {
+	s.Frames = append(s.Frames, append([]string{}, lines...))
+	return nil
+}
+
+func (s *SimulationScreen) ReadKey() (Key, error) {
+	if len(s.keys) == 0 {
+		return "", io.EOF
+	}
+	k := s.keys[0]
+	s.keys = s.keys[1:]
+	return k, nil
+}
+
+func (s *SimulationScreen) Suspend() error {
+	s.Suspended = true
+	return nil
+}
+
+func (s *SimulationScreen) Resume() error {
+	s.Suspended = false
+	return nil
+}
+
+func (s *SimulationScreen) Close() error {
+	return nil
+}
+
+// fit pads or cuts `s` to `width` characters, tabs being expanded
+func fit(s string, width int) string {
+	s = strings.Replace(s, "\t", "    ", -1)
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/moby/term"
+)
+
+// ANSI sequences driving the terminal
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+)
+
+// TerminalScreen draws on a terminal, in its alternate screen so that what
+// was displayed before comes back once done
+type TerminalScreen struct {
+	in    *os.File
+	out   *os.File
+	keys  *bufio.Reader
+	state *term.State
+}
+
+// NewTerminalScreen switches the terminal of `in` and `out` to raw mode and
+// to its alternate screen
+func NewTerminalScreen(in, out *os.File) (*TerminalScreen, error) {
+	if !term.IsTerminal(in.Fd()) || !term.IsTerminal(out.Fd()) {
+		return nil, fmt.Errorf("the interface needs a terminal")
+	}
+	s := &TerminalScreen{in: in, out: out, keys: bufio.NewReader(in)}
+	if err := s.Resume(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *TerminalScreen) Size() (int, int) {
+	ws, err := term.GetWinsize(s.out.Fd())
+	if err != nil || ws.Width == 0 || ws.Height == 0 {
+		return 80, 24
+	}
+	return int(ws.Width), int(ws.Height)
+}
+
+func (s *TerminalScreen) Draw(lines []string) error {
+	var buf bytes.Buffer
+	buf.WriteString(cursorHome)
+	for i, l := range lines {
+		if i > 0 {
+			// the output isn't post-processed in raw mode
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString(l + clearLine)
+	}
+	_, err := s.out.Write(buf.Bytes())
+	return err
+}
+
+func (s *TerminalScreen) ReadKey() (Key, error) {
+	r, _, err := s.keys.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch r {
+	case '\r', '\n':
+		return KeyEnter, nil
+	case '\t':
+		return KeyTab, nil
+	case 0x7f, 0x08:
+		return KeyBackspace, nil
+	case 0x03:
+		return KeyCtrlC, nil
+	case 0x1b:
+		// a lone escape, rather than the start of a sequence, comes
+		// alone
+		if s.keys.Buffered() == 0 {
+			return KeyEsc, nil
+		}
+		return s.readSequence()
+	}
+	return Key(r), nil
+}
+
+// readSequence reads the rest of an escape sequence, the keys it doesn't know
+// are read as an escape
+func (s *TerminalScreen) readSequence() (Key, error) {
+	b, err := s.keys.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if b != '[' && b != 'O' {
+		return KeyEsc, nil
+	}
+	var seq []byte
+	for {
+		b, err := s.keys.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		seq = append(seq, b)
+		if (b >= 'A' && b <= 'Z') || b == '~' {
+			break
+		}
+	}
+	switch string(seq) {
+	case "A":
+		return KeyUp, nil
+	case "B":
+		return KeyDown, nil
+	case "C":
+		return KeyRight, nil
+	case "D":
+		return KeyLeft, nil
+	case "H", "1~", "7~":
+		return KeyHome, nil
+	case "F", "4~", "8~":
+		return KeyEnd, nil
+	case "5~":
+		return KeyPageUp, nil
+	case "6~":
+		return KeyPageDown, nil
+	}
+	return KeyEsc, nil
+}
+
+// Suspend restores the terminal so that the commands run meanwhile can write
+// to it
+func (s *TerminalScreen) Suspend() error {
+	if s.state == nil {
+		return nil
+	}
+	if _, err := s.out.WriteString(exitAltScreen); err != nil {
+		return err
+	}
+	err := term.RestoreTerminal(s.in.Fd(), s.state)
+	s.state = nil
+	return err
+}
+
+func (s *TerminalScreen) Resume() error {
+	if s.state != nil {
+		return nil
+	}
+	state, err := term.MakeRaw(s.in.Fd())
+	if err != nil {
+		return err
+	}
+	s.state = state
+	_, err = s.out.WriteString(enterAltScreen)
+	return err
+}
+
+func (s *TerminalScreen) Close() error {
+	return s.Suspend()
+}